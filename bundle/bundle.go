@@ -0,0 +1,254 @@
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"julien/julien"
+	"os"
+	"path"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MAGIC marks the end of a bundle appended to an executable.
+const MAGIC string = "JULIENBZ"
+
+// Locations of the mounts within a bundle.
+const (
+	SITE     string = "index.md"
+	CONFIG   string = "julien.yaml"
+	CONTENT  string = "content"
+	FORMS    string = "forms"
+	STATIC   string = "static"
+	TEMPLATE string = "templates"
+)
+
+// trailer is the size of the zip length and magic written after
+// a bundle appended to an executable.
+const trailer int64 = 8 + int64(len(MAGIC))
+
+// Bundle is a read only file system holding the site file, config,
+// content, forms, template and static mounts of a site.
+type Bundle struct {
+	*zip.Reader
+	file *os.File
+}
+
+// Close closes the file the bundle is read from.
+func (b *Bundle) Close() error {
+	return b.file.Close()
+}
+
+// Config reads the bundled config on top of the default config.
+func (b *Bundle) Config() (julien.Julien, error) {
+	j := julien.DefaultJulien()
+	raw, err := fs.ReadFile(b, CONFIG)
+	if err != nil {
+		return j, err
+	}
+	err = yaml.Unmarshal(raw, &j)
+	return j, err
+}
+
+// Site reads the bundled site file.
+func (b *Bundle) Site() (julien.Site, error) {
+	site := julien.DefaultSite()
+	raw, err := fs.ReadFile(b, SITE)
+	if err != nil {
+		return site, err
+	}
+	err = julien.ParseSite(raw, &site)
+	return site, err
+}
+
+// offset returns the offset and size of a bundle appended to
+// an executable, or false if the file has no bundle appended.
+func offset(file *os.File) (int64, int64, bool) {
+	info, err := file.Stat()
+	if err != nil || info.Size() < trailer {
+		return 0, 0, false
+	}
+	tail := make([]byte, trailer)
+	if _, err := file.ReadAt(tail, info.Size()-trailer); err != nil {
+		return 0, 0, false
+	}
+	if string(tail[8:]) != MAGIC {
+		return 0, 0, false
+	}
+	size := int64(binary.LittleEndian.Uint64(tail[:8]))
+	start := info.Size() - trailer - size
+	if start < 0 {
+		return 0, 0, false
+	}
+	return start, size, true
+}
+
+// Open opens a bundle appended to an executable or a bundle archive.
+//
+// Parameters:
+// - ppath: The path to the executable or archive.
+//
+// Returns:
+// - The opened bundle.
+// - An error if the file holds no bundle.
+func Open(ppath string) (*Bundle, error) {
+	file, err := os.Open(ppath)
+	if err != nil {
+		return nil, err
+	}
+
+	var reader *zip.Reader
+	if start, size, ok := offset(file); ok {
+		reader, err = zip.NewReader(io.NewSectionReader(file, start, size), size)
+	} else {
+		var info os.FileInfo
+		info, err = file.Stat()
+		if err == nil {
+			reader, err = zip.NewReader(file, info.Size())
+		}
+	}
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("no bundle found in %s: %w", ppath, err)
+	}
+
+	return &Bundle{Reader: reader, file: file}, nil
+}
+
+// add writes every file below dir into the archive under prefix.
+func add(archive *zip.Writer, dir string, prefix string) error {
+	return filepath.WalkDir(dir, func(fpath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, fpath)
+		if err != nil {
+			return err
+		}
+		return addFile(archive, fpath, path.Join(prefix, filepath.ToSlash(rel)))
+	})
+}
+
+func addFile(archive *zip.Writer, fpath string, name string) error {
+	content, err := os.ReadFile(fpath)
+	if err != nil {
+		return err
+	}
+	writer, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(content)
+	return err
+}
+
+// Write writes the bundle archive of a site.
+//
+// Parameters:
+// - w: The writer the zip archive is written to.
+// - config: The site config, mount paths are rewritten to the bundle layout.
+// - sitepath: The path to the site file.
+//
+// Returns:
+// - An error if a mount cannot be read or the archive cannot be written.
+func Write(w io.Writer, config julien.Julien, sitepath string) error {
+	archive := zip.NewWriter(w)
+
+	if err := addFile(archive, sitepath, SITE); err != nil {
+		return err
+	}
+	if err := add(archive, config.ContentPath(), CONTENT); err != nil {
+		return err
+	}
+	if err := add(archive, config.FormsPath(), FORMS); err != nil {
+		return err
+	}
+	// Every template so overrides and parents are bundled too
+	if err := add(archive, config.Template.Path, TEMPLATE); err != nil {
+		return err
+	}
+	if _, err := os.Stat(config.StaticPath()); err == nil {
+		if err := add(archive, config.StaticPath(), STATIC); err != nil {
+			return err
+		}
+	}
+
+	// Only the data mount stays on disk
+	config.Content.Path = CONTENT
+	config.Forms.Path = FORMS
+	config.Static.Path = STATIC
+	config.Template.Path = TEMPLATE
+	raw, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	writer, err := archive.Create(CONFIG)
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(raw); err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+// Append writes a copy of the executable exe with the site bundle
+// appended to out, replacing any bundle exe already carries.
+//
+// Parameters:
+// - exe: The julien executable to copy.
+// - out: The path of the bundled executable.
+// - config: The site config.
+// - sitepath: The path to the site file.
+//
+// Returns:
+// - An error if the executable or the bundle cannot be written.
+func Append(exe string, out string, config julien.Julien, sitepath string) error {
+	source, err := os.Open(exe)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	info, err := source.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+	if start, _, ok := offset(source); ok {
+		size = start
+	}
+
+	archive := bytes.NewBuffer(nil)
+	if err := Write(archive, config, sitepath); err != nil {
+		return err
+	}
+
+	target, err := os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	defer target.Close()
+
+	if _, err := io.Copy(target, io.NewSectionReader(source, 0, size)); err != nil {
+		return err
+	}
+	if _, err := target.Write(archive.Bytes()); err != nil {
+		return err
+	}
+	tail := make([]byte, 8)
+	binary.LittleEndian.PutUint64(tail, uint64(archive.Len()))
+	if _, err := target.Write(append(tail, MAGIC...)); err != nil {
+		return err
+	}
+	return target.Sync()
+}
@@ -0,0 +1,99 @@
+package bundle
+
+import (
+	"io/fs"
+	"julien/julien"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func site(t *testing.T) (julien.Julien, string) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"index.md":                         "---\ntitle: Bundled\n---\nhello",
+		"content/index.md":                 "---\ntitle: Home\n---\n",
+		"forms/contact.md":                 "---\ntitle: Contact\n---\n",
+		"templates/julien/index.md":        "---\ntype: html\n---\n",
+		"templates/julien/views/page.html": "{{.Page}}",
+		"static/logo.txt":                  "logo",
+	}
+	for name, content := range files {
+		fpath := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(fpath), 0755))
+		assert.NoError(t, os.WriteFile(fpath, []byte(content), 0644))
+	}
+
+	j := julien.DefaultJulien()
+	j.Content.Path = filepath.Join(dir, "content")
+	j.Forms.Path = filepath.Join(dir, "forms")
+	j.Static.Path = filepath.Join(dir, "static")
+	j.Template.Path = filepath.Join(dir, "templates")
+	j.Data.Path = "/var/lib/julien"
+	return j, filepath.Join(dir, "index.md")
+}
+
+func TestArchive(t *testing.T) {
+	j, sitepath := site(t)
+	out := filepath.Join(t.TempDir(), "site.zip")
+	file, err := os.Create(out)
+	assert.NoError(t, err)
+	assert.NoError(t, Write(file, j, sitepath))
+	assert.NoError(t, file.Close())
+
+	b, err := Open(out)
+	assert.NoError(t, err)
+	defer b.Close()
+
+	config, err := b.Config()
+	assert.NoError(t, err)
+	assert.Equal(t, CONTENT, config.ContentPath())
+	assert.Equal(t, FORMS, config.FormsPath())
+	assert.Equal(t, STATIC, config.StaticPath())
+	assert.Equal(t, "templates/julien", config.TemplatePath())
+	assert.Equal(t, "/var/lib/julien", config.DataPath())
+
+	s, err := b.Site()
+	assert.NoError(t, err)
+	assert.Equal(t, "Bundled", s.GetString("title"))
+
+	for _, name := range []string{"content/index.md", "forms/contact.md", "templates/julien/views/page.html", "static/logo.txt"} {
+		_, err := fs.Stat(b, name)
+		assert.NoError(t, err, name)
+	}
+}
+
+func TestAppend(t *testing.T) {
+	j, sitepath := site(t)
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "julien")
+	assert.NoError(t, os.WriteFile(exe, []byte("not really an executable"), 0755))
+
+	_, err := Open(exe)
+	assert.Error(t, err)
+
+	first := filepath.Join(dir, "first")
+	assert.NoError(t, Append(exe, first, j, sitepath))
+
+	b, err := Open(first)
+	assert.NoError(t, err)
+	content, err := fs.ReadFile(b, "static/logo.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "logo", string(content))
+	b.Close()
+
+	// Bundling a bundled executable replaces its bundle
+	second := filepath.Join(dir, "second")
+	assert.NoError(t, Append(first, second, j, sitepath))
+	one, err := os.Stat(first)
+	assert.NoError(t, err)
+	two, err := os.Stat(second)
+	assert.NoError(t, err)
+	assert.Equal(t, one.Size(), two.Size())
+
+	raw, err := os.ReadFile(second)
+	assert.NoError(t, err)
+	assert.Equal(t, "not really an executable", string(raw[:24]))
+}
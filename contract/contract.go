@@ -5,6 +5,12 @@ type Driver interface {
 	Parse([]byte) (*map[string]interface{}, string, error)
 }
 
+// Detector is implemented by drivers that choose the concrete
+// driver for a document from its raw content
+type Detector interface {
+	Detect([]byte) Driver
+}
+
 type Doc interface {
 	// Document name
 	Name() string
@@ -0,0 +1,72 @@
+package driver
+
+import (
+	"bytes"
+	"julien/contract"
+)
+
+// Auto picks the yaml, toml or json driver by the opening delimiter of
+// each document so mixed content trees can share one mount.
+type Auto struct {
+	// Driver used for new documents and documents
+	// without a known opening delimiter
+	Default contract.Driver
+}
+
+func (a *Auto) Init(_ interface{}) error {
+	return nil
+}
+
+// Detect returns the driver matching the opening delimiter of raw.
+func (auto *Auto) Detect(raw []byte) contract.Driver {
+	_, driver, ok := Detect(raw)
+	if !ok {
+		return auto.fallback()
+	}
+	return driver
+}
+
+func (auto *Auto) fallback() contract.Driver {
+	if auto.Default == nil {
+		return &Yaml{}
+	}
+	return auto.Default
+}
+
+func (driver *Auto) Dump(frontmatter *map[string]interface{}, content string) ([]byte, error) {
+	return driver.fallback().Dump(frontmatter, content)
+}
+
+func (driver *Auto) Parse(raw []byte) (*map[string]interface{}, string, error) {
+	return driver.Detect(raw).Parse(raw)
+}
+
+// Detect returns the registered name and driver matching the opening
+// delimiter of raw.
+//
+// Returns:
+// - The registered driver name.
+// - The driver.
+// - false if raw does not start with a known delimiter.
+func Detect(raw []byte) (string, contract.Driver, bool) {
+	head := bytes.TrimLeft(bytes.TrimPrefix(raw, []byte("\xef\xbb\xbf")), " \t\r\n")
+	switch {
+	case bytes.HasPrefix(head, []byte("---")):
+		return DEFAULT, &Yaml{}, true
+	case bytes.HasPrefix(head, []byte("+++")):
+		return "toml-md", &Toml{}, true
+	case bytes.HasPrefix(head, []byte("{")):
+		return "json-md", &Json{}, true
+	}
+	return "", nil, false
+}
+
+// Resolve returns the driver that should read and write raw, picking the
+// detected driver when the given driver can detect formats.
+func Resolve(driver contract.Driver, raw []byte) contract.Driver {
+	detector, ok := driver.(contract.Detector)
+	if ok {
+		return detector.Detect(raw)
+	}
+	return driver
+}
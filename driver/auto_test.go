@@ -0,0 +1,34 @@
+package driver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuto_Detect(t *testing.T) {
+	auto := &Auto{Default: &Yaml{}}
+
+	assert.IsType(t, &Yaml{}, auto.Detect([]byte("---\ntitle: yaml\n---\n")))
+	assert.IsType(t, &Toml{}, auto.Detect([]byte("+++\ntitle = \"toml\"\n+++\n")))
+	assert.IsType(t, &Json{}, auto.Detect([]byte("\n{\"title\": \"json\"}\n")))
+	assert.IsType(t, &Yaml{}, auto.Detect([]byte("")))
+}
+
+func TestAuto_RoundTrip(t *testing.T) {
+	auto := &Auto{Default: &Yaml{}}
+	raw := []byte("+++\ntitle = \"My Title\"\n+++\nThis is the content.")
+
+	// Documents are dumped with the driver they were parsed with
+	resolved := Resolve(auto, raw)
+	frontmatter, content, err := resolved.Parse(raw)
+	assert.NoError(t, err)
+
+	result, err := resolved.Dump(frontmatter, content)
+	assert.NoError(t, err)
+	assert.Equal(t, string(raw), string(result))
+
+	// Drivers that can't detect resolve to themselves
+	yamler := &Yaml{}
+	assert.Equal(t, yamler, Resolve(yamler, raw))
+}
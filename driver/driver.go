@@ -0,0 +1,68 @@
+package driver
+
+import (
+	"fmt"
+	"julien/contract"
+	"sort"
+	"sync"
+)
+
+// DEFAULT is the driver name used when a mount or form does not set one.
+const DEFAULT string = "yaml-md"
+
+var lock sync.RWMutex
+
+var drivers = map[string]contract.Driver{
+	"yaml":    &Yaml{},
+	"json":    &Json{},
+	"toml":    &Toml{},
+	"auto":    &Auto{Default: &Yaml{}},
+	"json-md": &Json{},
+	"toml-md": &Toml{},
+	DEFAULT:   &Yaml{},
+}
+
+// Register makes a driver available by name, replacing any driver
+// previously registered with the same name.
+//
+// Parameters:
+// - name: The name used to reference the driver in julien.yaml and frontmatter.
+// - driver: The driver implementation.
+func Register(name string, driver contract.Driver) {
+	lock.Lock()
+	defer lock.Unlock()
+	drivers[name] = driver
+}
+
+// Find looks up a registered driver by name.
+//
+// Parameters:
+// - name: The registered driver name, an empty name resolves to DEFAULT.
+//
+// Returns:
+// - The registered driver.
+// - An error if no driver is registered with the given name.
+func Find(name string) (contract.Driver, error) {
+	if name == "" {
+		name = DEFAULT
+	}
+	lock.RLock()
+	defer lock.RUnlock()
+	driver, ok := drivers[name]
+	if !ok {
+		return nil, fmt.Errorf("driver not found: %s", name)
+	}
+	return driver, nil
+}
+
+// Names returns the sorted names of all registered drivers.
+func Names() []string {
+	lock.RLock()
+	defer lock.RUnlock()
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
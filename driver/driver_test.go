@@ -0,0 +1,34 @@
+package driver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFind(t *testing.T) {
+	yamler, err := Find("yaml-md")
+	assert.NoError(t, err)
+	assert.IsType(t, &Yaml{}, yamler)
+
+	// Empty name resolves to the default driver
+	yamler, err = Find("")
+	assert.NoError(t, err)
+	assert.IsType(t, &Yaml{}, yamler)
+
+	_, err = Find("unknown")
+	assert.EqualError(t, err, "driver not found: unknown")
+}
+
+func TestRegister(t *testing.T) {
+	Register("test-md", &Yaml{})
+	defer func() {
+		lock.Lock()
+		delete(drivers, "test-md")
+		lock.Unlock()
+	}()
+
+	_, err := Find("test-md")
+	assert.NoError(t, err)
+	assert.Contains(t, Names(), "test-md")
+}
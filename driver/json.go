@@ -0,0 +1,62 @@
+package driver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Json reads and writes documents with a JSON object as frontmatter
+// followed by the markdown body, the format Hugo uses for `{ ... }`
+// frontmatter.
+type Json struct{}
+
+func (j *Json) Init(_ interface{}) error {
+	return nil
+}
+
+func (driver *Json) Dump(frontmatter *map[string]interface{}, content string) ([]byte, error) {
+	head := Denormalize(*frontmatter)
+	json, err := json.MarshalIndent(head, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return []byte(string(json) + "\n" + content), nil
+}
+
+func (driver *Json) Parse(raw []byte) (*map[string]interface{}, string, error) {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return &map[string]interface{}{}, "", nil
+	}
+
+	frontmatter := make(map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&frontmatter); err != nil {
+		return nil, "", err
+	}
+
+	content := string(raw[decoder.InputOffset():])
+	content = strings.TrimPrefix(content, "\r")
+	content = strings.TrimPrefix(content, "\n")
+
+	return normalizeFrontmatter(frontmatter), content, nil
+}
+
+func (driver *Json) Parts(content string) (string, string, error) {
+	if content == "" {
+		return "", "", nil
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(content))
+	var head json.RawMessage
+	if err := decoder.Decode(&head); err != nil {
+		return "", "", fmt.Errorf("no match found")
+	}
+
+	body := content[decoder.InputOffset():]
+	body = strings.TrimPrefix(body, "\r")
+	body = strings.TrimPrefix(body, "\n")
+	return string(head), body, nil
+}
@@ -0,0 +1,40 @@
+package driver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJson_Parse(t *testing.T) {
+	driver := &Json{}
+
+	raw := "{\n  \"title\": \"My Title\",\n  \"weight\": 3,\n  \"ratio\": 1.5,\n  \"page\": {\"view\": \"article\"}\n}\nThis is the content."
+
+	frontmatter, content, err := driver.Parse([]byte(raw))
+	assert.NoError(t, err)
+	assert.Equal(t, "This is the content.", content)
+	assert.Equal(t, "My Title", (*frontmatter)["title"])
+	assert.Equal(t, 3, (*frontmatter)["weight"])
+	assert.Equal(t, 1.5, (*frontmatter)["ratio"])
+
+	// Nested maps match the yaml driver types
+	page, ok := (*frontmatter)["page"].(map[interface{}]interface{})
+	assert.True(t, ok)
+	assert.Equal(t, "article", page["view"])
+}
+
+func TestJson_Dump(t *testing.T) {
+	driver := &Json{}
+
+	frontmatter := &map[string]interface{}{
+		"title": "My Title",
+		"page":  map[interface{}]interface{}{"view": "article"},
+	}
+
+	expected := "{\n  \"page\": {\n    \"view\": \"article\"\n  },\n  \"title\": \"My Title\"\n}\nThis is the content."
+
+	result, err := driver.Dump(frontmatter, "This is the content.")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, string(result))
+}
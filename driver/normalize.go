@@ -0,0 +1,81 @@
+package driver
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// normalize converts decoded frontmatter values into the types the
+// yaml driver produces, so templates and forms can type assert
+// nested maps as map[interface{}]interface{} and numbers as int
+// whichever driver parsed the document.
+func normalize(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		mapped := make(map[interface{}]interface{}, len(value))
+		for key, item := range value {
+			mapped[key] = normalize(item)
+		}
+		return mapped
+
+	case []map[string]interface{}:
+		items := make([]interface{}, len(value))
+		for index, item := range value {
+			items[index] = normalize(item)
+		}
+		return items
+
+	case []interface{}:
+		for index, item := range value {
+			value[index] = normalize(item)
+		}
+		return value
+
+	case json.Number:
+		if number, err := value.Int64(); err == nil {
+			return int(number)
+		}
+		number, _ := value.Float64()
+		return number
+
+	case int64:
+		return int(value)
+	}
+	return value
+}
+
+// normalizeFrontmatter normalizes every value of a decoded frontmatter.
+func normalizeFrontmatter(frontmatter map[string]interface{}) *map[string]interface{} {
+	for key, value := range frontmatter {
+		frontmatter[key] = normalize(value)
+	}
+	return &frontmatter
+}
+
+// Denormalize converts nested map[interface{}]interface{} values back into
+// map[string]interface{} for encoders that only support string keys.
+func Denormalize(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		mapped := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			mapped[fmt.Sprint(key)] = Denormalize(item)
+		}
+		return mapped
+
+	case map[string]interface{}:
+		mapped := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			mapped[key] = Denormalize(item)
+		}
+		return mapped
+
+	case []interface{}:
+		items := make([]interface{}, len(value))
+		for index, item := range value {
+			items[index] = Denormalize(item)
+		}
+		return items
+	}
+	return value
+}
@@ -0,0 +1,60 @@
+package driver
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Toml reads and writes documents with TOML frontmatter between `+++` lines.
+type Toml struct{}
+
+func (t *Toml) Init(_ interface{}) error {
+	return nil
+}
+
+func (driver *Toml) Dump(frontmatter *map[string]interface{}, content string) ([]byte, error) {
+	buffer := bytes.NewBuffer(nil)
+	encoder := toml.NewEncoder(buffer)
+	encoder.Indent = ""
+	if err := encoder.Encode(Denormalize(*frontmatter)); err != nil {
+		return nil, err
+	}
+	return []byte("+++\n" + buffer.String() + "+++\n" + content), nil
+}
+
+func (driver *Toml) Parse(raw []byte) (*map[string]interface{}, string, error) {
+	head, content, err := driver.Parts(string(raw))
+	if err != nil {
+		return nil, "", err
+	}
+
+	frontmatter := make(map[string]interface{})
+	if _, err := toml.Decode(head, &frontmatter); err != nil {
+		return nil, "", err
+	}
+
+	return normalizeFrontmatter(frontmatter), content, nil
+}
+
+func (driver *Toml) Parts(content string) (string, string, error) {
+	if content == "" {
+		return "", "", nil
+	}
+
+	re := regexp.MustCompile(`(?m)^\s*\+\+\+\n([\s\S]+?)\n\+\+\+(\n([\S\s]+))?$`)
+	matches := re.FindStringSubmatch(content)
+	matched_len := len(matches)
+
+	if matched_len == 2 {
+		return matches[1], "", nil
+	}
+
+	if matched_len == 4 {
+		return matches[1], matches[3], nil
+	}
+
+	return "", "", fmt.Errorf("no match found")
+}
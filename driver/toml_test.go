@@ -0,0 +1,42 @@
+package driver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToml_Parse(t *testing.T) {
+	driver := &Toml{}
+
+	raw := "+++\ntitle = \"My Title\"\nweight = 3\ntags = [\"tag1\", \"tag2\"]\n\n[page]\nview = \"article\"\n+++\nThis is the content."
+
+	frontmatter, content, err := driver.Parse([]byte(raw))
+	assert.NoError(t, err)
+	assert.Equal(t, "This is the content.", content)
+	assert.Equal(t, "My Title", (*frontmatter)["title"])
+	assert.Equal(t, 3, (*frontmatter)["weight"])
+	assert.Equal(t, []interface{}{"tag1", "tag2"}, (*frontmatter)["tags"])
+
+	page, ok := (*frontmatter)["page"].(map[interface{}]interface{})
+	assert.True(t, ok)
+	assert.Equal(t, "article", page["view"])
+
+	_, _, err = driver.Parse([]byte("---\ntitle: yaml\n---\n"))
+	assert.EqualError(t, err, "no match found")
+}
+
+func TestToml_Dump(t *testing.T) {
+	driver := &Toml{}
+
+	frontmatter := &map[string]interface{}{
+		"title": "My Title",
+		"tags":  []string{"tag1", "tag2"},
+	}
+
+	expected := "+++\ntags = [\"tag1\", \"tag2\"]\ntitle = \"My Title\"\n+++\nThis is the content."
+
+	result, err := driver.Dump(frontmatter, "This is the content.")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, string(result))
+}
@@ -0,0 +1,400 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// Formats of the feeds of a section, by file name
+
+const RSS string = "feed.xml"
+
+const ATOM string = "atom.xml"
+
+const JSON string = "feed.json"
+
+// LIMIT is the number of items of a feed unless configured
+const LIMIT int = 20
+
+// Format is a feed document type served under a file name
+type Format struct {
+	Name   string // Name used in the formats option
+	File   string // File name below the section path
+	Type   string // Content type
+	render func(*Feed) ([]byte, error)
+}
+
+var FORMATS = []Format{
+	{Name: "rss", File: RSS, Type: "application/rss+xml", render: (*Feed).RSS},
+	{Name: "atom", File: ATOM, Type: "application/atom+xml", render: (*Feed).Atom},
+	{Name: "json", File: JSON, Type: "application/feed+json", render: (*Feed).JSON},
+}
+
+// Options are the feed options of a section
+type Options struct {
+	Limit   int
+	Formats []Format
+}
+
+type Feed struct {
+	Title       string
+	Description string
+	URL         string // Absolute url of the section page
+	FeedURL     string // Absolute url of the feed document, set by Render
+	Author      string
+	Language    string
+	Updated     time.Time
+	Items       []Item
+}
+
+type Item struct {
+	ID        string
+	Title     string
+	URL       string
+	Summary   string
+	Content   string // Html content
+	Author    string
+	Tags      []string
+	Published time.Time
+	Updated   time.Time
+}
+
+// Find returns the format served under a file name
+func Find(file string) (Format, bool) {
+	for _, format := range FORMATS {
+		if format.File == file {
+			return format, true
+		}
+	}
+	return Format{}, false
+}
+
+// Parse reads the feed option of a section, either true for every
+// format or a map with a limit and a list of format names e.g
+// feed: {limit: 10, formats: [rss, json]}
+//
+// Parameters:
+// - value: The feed frontmatter value.
+//
+// Returns:
+// - The options and true if the section has a feed.
+// - An error for unknown formats or a malformed option.
+func Parse(value interface{}) (Options, bool, error) {
+	options := Options{Limit: LIMIT, Formats: FORMATS}
+	switch value := value.(type) {
+	case nil:
+		return options, false, nil
+	case bool:
+		return options, value, nil
+	case map[interface{}]interface{}:
+		if limit, ok := value["limit"]; ok {
+			if options.Limit, ok = limit.(int); !ok || options.Limit < 1 {
+				return options, false, fmt.Errorf("feed limit must be a positive number: %v", limit)
+			}
+		}
+		if names, ok := value["formats"]; ok {
+			list, ok := names.([]interface{})
+			if !ok {
+				return options, false, fmt.Errorf("feed formats must be a list: %v", names)
+			}
+			options.Formats = make([]Format, 0, len(list))
+			for _, name := range list {
+				format, err := named(fmt.Sprint(name))
+				if err != nil {
+					return options, false, err
+				}
+				options.Formats = append(options.Formats, format)
+			}
+		}
+		return options, true, nil
+	default:
+		return options, false, fmt.Errorf("feed must be true or a map: %v", value)
+	}
+}
+
+func named(name string) (Format, error) {
+	for _, format := range FORMATS {
+		if format.Name == name {
+			return format, nil
+		}
+	}
+	return Format{}, fmt.Errorf("feed format not found: %s", name)
+}
+
+// Has reports whether the options include a format
+func (options Options) Has(format Format) bool {
+	for _, included := range options.Formats {
+		if included.File == format.File {
+			return true
+		}
+	}
+	return false
+}
+
+// Render writes the feed in a format served below the section url
+func (f *Feed) Render(format Format) ([]byte, error) {
+	feed := *f
+	feed.FeedURL = strings.TrimRight(f.URL, "/") + "/" + format.File
+	return format.render(&feed)
+}
+
+// Link returns the url of a format of a section feed
+func Link(section string, format Format) string {
+	return path.Join("/", section, format.File)
+}
+
+// rfc822 is the date format of rss
+func rfc822(date time.Time) string {
+	return date.Format(time.RFC1123Z)
+}
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Content string     `xml:"xmlns:content,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Language      string    `xml:"language,omitempty"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Self          atomLink  `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+	Author      string   `xml:"author,omitempty"`
+	Categories  []string `xml:"category"`
+	Description string   `xml:"description"`
+	Content     string   `xml:"content:encoded,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// RSS renders the feed as an RSS 2.0 document
+func (f *Feed) RSS() ([]byte, error) {
+	channel := rssChannel{
+		Title:       f.Title,
+		Link:        f.URL,
+		Description: f.Description,
+		Language:    f.Language,
+		Self:        atomLink{Href: f.FeedURL, Rel: "self", Type: "application/rss+xml"},
+		Items:       make([]rssItem, 0, len(f.Items)),
+	}
+	if !f.Updated.IsZero() {
+		channel.LastBuildDate = rfc822(f.Updated)
+	}
+	for _, item := range f.Items {
+		description := item.Summary
+		if description == "" {
+			description = item.Content
+		}
+		entry := rssItem{
+			Title:       item.Title,
+			Link:        item.URL,
+			GUID:        rssGUID{IsPermaLink: item.ID == item.URL, Value: item.ID},
+			Categories:  item.Tags,
+			Description: description,
+			Content:     item.Content,
+		}
+		if !item.Published.IsZero() {
+			entry.PubDate = rfc822(item.Published)
+		}
+		channel.Items = append(channel.Items, entry)
+	}
+	return document(rss{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		Content: "http://purl.org/rss/1.0/modules/content/",
+		Channel: channel,
+	})
+}
+
+type atom struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  *atomAuthor `xml:"author,omitempty"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published,omitempty"`
+	Updated    string         `xml:"updated"`
+	Author     *atomAuthor    `xml:"author,omitempty"`
+	Categories []atomCategory `xml:"category"`
+	Summary    *atomText      `xml:"summary,omitempty"`
+	Content    *atomText      `xml:"content,omitempty"`
+}
+
+// Atom renders the feed as an Atom 1.0 document
+func (f *Feed) Atom() ([]byte, error) {
+	updated := f.Updated
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+	doc := atom{
+		Title:   f.Title,
+		ID:      f.URL,
+		Updated: updated.Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: f.URL, Rel: "alternate", Type: "text/html"},
+			{Href: f.FeedURL, Rel: "self", Type: "application/atom+xml"},
+		},
+		Entries: make([]atomEntry, 0, len(f.Items)),
+	}
+	if f.Author != "" {
+		doc.Author = &atomAuthor{Name: f.Author}
+	}
+	for _, item := range f.Items {
+		entry := atomEntry{
+			Title:      item.Title,
+			ID:         item.ID,
+			Link:       atomLink{Href: item.URL, Rel: "alternate"},
+			Categories: make([]atomCategory, 0, len(item.Tags)),
+		}
+		// Entries need an update date, fall back to the feed one
+		entry.Updated = updated.Format(time.RFC3339)
+		if !item.Updated.IsZero() {
+			entry.Updated = item.Updated.Format(time.RFC3339)
+		}
+		if !item.Published.IsZero() {
+			entry.Published = item.Published.Format(time.RFC3339)
+		}
+		if item.Author != "" {
+			entry.Author = &atomAuthor{Name: item.Author}
+		}
+		for _, tag := range item.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+		if item.Summary != "" {
+			entry.Summary = &atomText{Type: "text", Value: item.Summary}
+		}
+		if item.Content != "" {
+			entry.Content = &atomText{Type: "html", Value: item.Content}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return document(doc)
+}
+
+type jsonFeed struct {
+	Version     string       `json:"version"`
+	Title       string       `json:"title"`
+	HomePageURL string       `json:"home_page_url,omitempty"`
+	FeedURL     string       `json:"feed_url,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Language    string       `json:"language,omitempty"`
+	Authors     []jsonAuthor `json:"authors,omitempty"`
+	Items       []jsonItem   `json:"items"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonItem struct {
+	ID            string       `json:"id"`
+	URL           string       `json:"url,omitempty"`
+	Title         string       `json:"title,omitempty"`
+	ContentHTML   string       `json:"content_html"`
+	Summary       string       `json:"summary,omitempty"`
+	DatePublished string       `json:"date_published,omitempty"`
+	DateModified  string       `json:"date_modified,omitempty"`
+	Authors       []jsonAuthor `json:"authors,omitempty"`
+	Tags          []string     `json:"tags,omitempty"`
+}
+
+// JSON renders the feed as a JSON Feed 1.1 document
+func (f *Feed) JSON() ([]byte, error) {
+	doc := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageURL: f.URL,
+		FeedURL:     f.FeedURL,
+		Description: f.Description,
+		Language:    f.Language,
+		Items:       make([]jsonItem, 0, len(f.Items)),
+	}
+	if f.Author != "" {
+		doc.Authors = []jsonAuthor{{Name: f.Author}}
+	}
+	for _, item := range f.Items {
+		entry := jsonItem{
+			ID:          item.ID,
+			URL:         item.URL,
+			Title:       item.Title,
+			ContentHTML: item.Content,
+			Summary:     item.Summary,
+			Tags:        item.Tags,
+		}
+		if !item.Published.IsZero() {
+			entry.DatePublished = item.Published.Format(time.RFC3339)
+		}
+		if !item.Updated.IsZero() {
+			entry.DateModified = item.Updated.Format(time.RFC3339)
+		}
+		if item.Author != "" {
+			entry.Authors = []jsonAuthor{{Name: item.Author}}
+		}
+		doc.Items = append(doc.Items, entry)
+	}
+	// Keep the html content readable
+	body := bytes.NewBuffer(nil)
+	encoder := json.NewEncoder(body)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	return body.Bytes(), nil
+}
+
+// document marshals an xml document with its header
+func document(doc interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
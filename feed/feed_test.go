@@ -0,0 +1,119 @@
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sample() *Feed {
+	published := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	return &Feed{
+		Title:       "Articles",
+		Description: "All the articles",
+		URL:         "https://julien.dev/articles",
+		Author:      "Julien",
+		Updated:     published,
+		Items: []Item{
+			{
+				ID:        "https://julien.dev/articles/one",
+				Title:     "One & only",
+				URL:       "https://julien.dev/articles/one",
+				Content:   "<p>First <em>post</em></p>",
+				Tags:      []string{"go"},
+				Published: published,
+			},
+		},
+	}
+}
+
+func TestRSS(t *testing.T) {
+	body, err := sample().Render(FORMATS[0])
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(body), xml.Header))
+
+	var doc struct {
+		Channel struct {
+			Title string `xml:"title"`
+			Items []struct {
+				Title       string `xml:"title"`
+				PubDate     string `xml:"pubDate"`
+				Description string `xml:"description"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	assert.NoError(t, xml.Unmarshal(body, &doc))
+	assert.Equal(t, "Articles", doc.Channel.Title)
+	assert.Equal(t, "One & only", doc.Channel.Items[0].Title)
+	assert.Equal(t, "Wed, 01 May 2024 10:00:00 +0000", doc.Channel.Items[0].PubDate)
+	assert.Equal(t, "<p>First <em>post</em></p>", doc.Channel.Items[0].Description)
+	assert.Contains(t, string(body), `href="https://julien.dev/articles/feed.xml"`)
+}
+
+func TestAtom(t *testing.T) {
+	body, err := sample().Render(FORMATS[1])
+	assert.NoError(t, err)
+
+	var doc struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		Links   []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Entries []struct {
+			Updated string `xml:"updated"`
+			Content string `xml:"content"`
+		} `xml:"entry"`
+	}
+	assert.NoError(t, xml.Unmarshal(body, &doc))
+	assert.Equal(t, "https://julien.dev/articles/atom.xml", doc.Links[1].Href)
+	assert.Equal(t, "2024-05-01T10:00:00Z", doc.Entries[0].Updated)
+	assert.Equal(t, "<p>First <em>post</em></p>", doc.Entries[0].Content)
+}
+
+func TestJSON(t *testing.T) {
+	body, err := sample().Render(FORMATS[2])
+	assert.NoError(t, err)
+
+	var doc map[string]interface{}
+	assert.NoError(t, json.Unmarshal(body, &doc))
+	assert.Equal(t, "https://jsonfeed.org/version/1.1", doc["version"])
+	assert.Equal(t, "https://julien.dev/articles/feed.json", doc["feed_url"])
+	item := doc["items"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "<p>First <em>post</em></p>", item["content_html"])
+	assert.Equal(t, "2024-05-01T10:00:00Z", item["date_published"])
+}
+
+func TestParse(t *testing.T) {
+	_, ok, err := Parse(nil)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	options, ok, err := Parse(true)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, LIMIT, options.Limit)
+	assert.Len(t, options.Formats, 3)
+
+	options, ok, err = Parse(map[interface{}]interface{}{"limit": 5, "formats": []interface{}{"json"}})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 5, options.Limit)
+	assert.True(t, options.Has(FORMATS[2]))
+	assert.False(t, options.Has(FORMATS[0]))
+
+	_, _, err = Parse(map[interface{}]interface{}{"formats": []interface{}{"gopher"}})
+	assert.Error(t, err)
+	_, _, err = Parse("yes")
+	assert.Error(t, err)
+
+	format, ok := Find("atom.xml")
+	assert.True(t, ok)
+	assert.Equal(t, "atom", format.Name)
+	assert.Equal(t, "/articles/atom.xml", Link("articles", format))
+	assert.Equal(t, "/feed.json", Link("", FORMATS[2]))
+}
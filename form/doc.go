@@ -1,20 +1,22 @@
 package form
 
 import (
+	"julien/contract"
 	"julien/fs"
 	"strings"
 	"time"
 )
 
 type Doc struct {
-	meta  map[string]interface{}
-	body  string
-	entry *fs.Entry
-	form  *Form
+	meta   map[string]interface{}
+	body   string
+	entry  *fs.Entry
+	form   *Form
+	driver contract.Driver
 }
 
 func (doc *Doc) Name() string {
-	return strings.Trim(doc.entry.Name(), doc.Ext())
+	return strings.TrimSuffix(doc.entry.Name(), "."+doc.Ext())
 }
 
 func (doc *Doc) Path() string {
@@ -100,7 +102,7 @@ func (doc *Doc) Fill(frontmatter map[string]interface{}) {
 }
 
 func (doc *Doc) Dump() ([]byte, error) {
-	bytes, err := doc.form.root.driver.Dump(&doc.meta, doc.body)
+	bytes, err := doc.driver.Dump(&doc.meta, doc.body)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,255 @@
+package form
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	jutils "julien/utils"
+	"path"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// FILES_DIR is appended to the name of a submission for the directory
+// of its files e.g contact-us/bob.files/cv/1-resume.pdf
+const FILES_DIR string = ".files"
+
+// MAX_FILE_SIZE is the size limit of a file unless configured
+const MAX_FILE_SIZE int64 = 10 << 20
+
+// Error tags of file fields, set like the validation errors of a form
+
+const FILE_REQUIRED string = "required"
+
+const FILE_SIZE string = "max_size"
+
+const FILE_COUNT string = "max_count"
+
+const FILE_TYPE string = "type"
+
+// FileField is a file field declared under the files key of a form
+type FileField struct {
+	Name     string
+	Required bool
+	MaxSize  int64    // Size limit of each file in bytes
+	MaxCount int      // Number of files of the field
+	Types    []string // Mime types e.g application/pdf or image/*, any if empty
+}
+
+// Upload is a file submitted to a file field
+type Upload struct {
+	Name    string // File name of the client
+	Content []byte
+}
+
+// Attachment is a stored file recorded in the submission frontmatter
+type Attachment struct {
+	Name   string
+	Size   int64
+	Type   string
+	SHA256 string
+	Path   string // Path of the file in the data mount
+}
+
+// FileFields reads the files key of the form e.g
+// files: {cv: {required: true, max_size: 5MB, max_count: 1, types: [application/pdf]}}
+//
+// Returns:
+// - The file fields by name, empty if the form has no files key.
+// - An error for a malformed files key.
+func (fm *Form) FileFields() (map[string]FileField, error) {
+	fields := make(map[string]FileField)
+	value := fm.Get("files")
+	if value == nil {
+		return fields, nil
+	}
+	specs, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("form %s: files must be a map", fm.Name())
+	}
+	for key, value := range specs {
+		name := fmt.Sprint(key)
+		field := FileField{Name: name, MaxSize: MAX_FILE_SIZE, MaxCount: 1, Types: make([]string, 0)}
+		spec, ok := value.(map[interface{}]interface{})
+		if !ok && value != nil {
+			return nil, fmt.Errorf("form %s: file field %s must be a map", fm.Name(), name)
+		}
+		if required, ok := spec["required"].(bool); ok {
+			field.Required = required
+		}
+		if size, ok := spec["max_size"]; ok {
+			limit, err := jutils.ParseSize(size)
+			if err != nil {
+				return nil, fmt.Errorf("form %s: file field %s: %w", fm.Name(), name, err)
+			}
+			field.MaxSize = limit
+		}
+		if count, ok := spec["max_count"]; ok {
+			if field.MaxCount, ok = count.(int); !ok || field.MaxCount < 1 {
+				return nil, fmt.Errorf("form %s: file field %s: max_count must be a positive number", fm.Name(), name)
+			}
+		}
+		switch types := spec["types"].(type) {
+		case string:
+			field.Types = append(field.Types, types)
+		case []interface{}:
+			for _, mime := range types {
+				field.Types = append(field.Types, fmt.Sprint(mime))
+			}
+		}
+		fields[name] = field
+	}
+	return fields, nil
+}
+
+// Check validates the uploads of a field, types are checked against
+// the content of the files and not the type sent by the client.
+//
+// Parameters:
+// - uploads: The files submitted to the field.
+//
+// Returns:
+// - The error tags of the field, empty if the uploads are valid.
+func (field FileField) Check(uploads []Upload) []string {
+	errors := make([]string, 0)
+	if len(uploads) == 0 {
+		if field.Required {
+			errors = append(errors, FILE_REQUIRED)
+		}
+		return errors
+	}
+	if len(uploads) > field.MaxCount {
+		errors = append(errors, FILE_COUNT)
+	}
+	for _, upload := range uploads {
+		if int64(len(upload.Content)) > field.MaxSize && !jutils.ArrayIncludes(errors, FILE_SIZE) {
+			errors = append(errors, FILE_SIZE)
+		}
+		if !field.Accepts(upload.Content) && !jutils.ArrayIncludes(errors, FILE_TYPE) {
+			errors = append(errors, FILE_TYPE)
+		}
+	}
+	return errors
+}
+
+// Accepts reports whether the detected type of content is allowed
+func (field FileField) Accepts(content []byte) bool {
+	if len(field.Types) == 0 {
+		return true
+	}
+	detected := mimetype.Detect(content)
+	for _, allowed := range field.Types {
+		if group, ok := strings.CutSuffix(allowed, "/*"); ok {
+			for mime := detected; mime != nil; mime = mime.Parent() {
+				if strings.HasPrefix(mime.String(), group+"/") {
+					return true
+				}
+			}
+			continue
+		}
+		for mime := detected; mime != nil; mime = mime.Parent() {
+			if mime.Is(allowed) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Attach stores the uploads of a field next to the submission and
+// records them under the field key of its frontmatter, the
+// submission must be saved to keep the record.
+//
+// Parameters:
+// - field: The name of the file field.
+// - uploads: The checked files of the field.
+//
+// Returns:
+// - The stored attachments.
+// - An error if a file cannot be stored.
+func (doc *Doc) Attach(field string, uploads []Upload) ([]Attachment, error) {
+	attachments := make([]Attachment, 0, len(uploads))
+	records := make([]interface{}, 0, len(uploads))
+	for i, upload := range uploads {
+		mime := mimetype.Detect(upload.Content)
+		name := FileName(upload.Name, mime.Extension())
+		sum := sha256.Sum256(upload.Content)
+		attachment := Attachment{
+			Name:   path.Base("/" + upload.Name),
+			Size:   int64(len(upload.Content)),
+			Type:   mime.String(),
+			SHA256: hex.EncodeToString(sum[:]),
+			Path:   path.Join(doc.FilesPath(), field, fmt.Sprintf("%d-%s", i+1, name)),
+		}
+		if err := doc.form.root.data.Dump(attachment.Path, upload.Content); err != nil {
+			return attachments, err
+		}
+		attachments = append(attachments, attachment)
+		records = append(records, map[string]interface{}{
+			"name":   attachment.Name,
+			"size":   attachment.Size,
+			"type":   attachment.Type,
+			"sha256": attachment.SHA256,
+			"path":   attachment.Path,
+		})
+	}
+	doc.Set(field, records)
+	return attachments, nil
+}
+
+// FilesPath returns the directory of the files of the submission
+func (doc *Doc) FilesPath() string {
+	return path.Join(doc.form.Name(), doc.Name()+FILES_DIR)
+}
+
+// File reads a file attached to a submission of the form, paths
+// outside of the files directories of the form are not found.
+//
+// Parameters:
+// - ppath: The path of the file in the data mount as recorded.
+//
+// Returns:
+// - The content of the file.
+// - An error if the path is not a file of a submission or cannot be read.
+func (fm *Form) File(ppath string) ([]byte, error) {
+	ppath = strings.TrimPrefix(path.Clean("/"+ppath), "/")
+	parts := strings.Split(ppath, "/")
+	if len(parts) != 4 || parts[0] != fm.Name() || !strings.HasSuffix(parts[1], FILES_DIR) {
+		return nil, fmt.Errorf("file not found: %s", ppath)
+	}
+	return fm.root.data.Read(ppath)
+}
+
+// FileName returns a file name safe to store, the extension of the
+// detected type is used for names without one
+func FileName(name string, ext string) string {
+	name = path.Base("/" + strings.ReplaceAll(name, "\\", "/"))
+	safe := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		default:
+			return '-'
+		}
+	}, name)
+	safe = strings.Trim(safe, ".-")
+	if len(safe) > 100 {
+		safe = safe[len(safe)-100:]
+	}
+	if safe == "" {
+		safe = "file"
+	}
+	if path.Ext(safe) == "" {
+		if ext == "" {
+			ext = ".bin"
+		}
+		safe += ext
+	}
+	return safe
+}
+
+// Detach removes the stored files of the submission
+func (doc *Doc) Detach() error {
+	return doc.form.root.data.Remove(doc.FilesPath())
+}
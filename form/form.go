@@ -1,10 +1,17 @@
 package form
 
 import (
+	"errors"
+	"fmt"
+	iofs "io/fs"
 	"julien/contract"
+	"julien/driver"
 	"julien/fs"
+	jutils "julien/utils"
 	"path"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v2/log"
@@ -12,29 +19,106 @@ import (
 
 var EMPTY_DOC_ARRAY = make([]*Doc, 0)
 
+// Collision strategies applied when a submission name is taken
+
+// Reject the submission
+const COLLISION_FAIL string = "fail"
+
+// Store the submission under the name with a numeric suffix
+const COLLISION_SUFFIX string = "suffix"
+
+// Replace the existing submission
+const COLLISION_OVERWRITE string = "overwrite"
+
+// Merge the submission values into the existing submission
+const COLLISION_MERGE string = "merge"
+
+// Highest suffix tried before a submission is rejected
+const MAX_SUFFIX int = 1000
+
 type Root struct {
-	disk   *fs.Disk
-	data   *fs.Disk
-	driver contract.Driver
+	disk    fs.Storage
+	data    fs.Storage
+	driver  contract.Driver
+	ddriver contract.Driver
+	lock    *sync.Mutex            // Serializes merges of submissions
+	action  string                 // Base url forms post to
+	forms   *jutils.Cache[*Form]   // Parsed forms by path once indexed
+	list    *jutils.Cache[[]*Form] // Parsed forms list once indexed
 }
 
 type Form struct {
-	meta  map[string]interface{}
-	body  string
-	entry *fs.Entry
-	root  *Root
+	meta   map[string]interface{}
+	body   string
+	entry  *fs.Entry
+	root   *Root
+	format contract.Driver // Driver of the form document itself
+	driver contract.Driver // Driver of the form submissions
 }
 
-func Init(fdisk *fs.Disk, ddisk *fs.Disk, driver contract.Driver) Root {
+func Init(fdisk fs.Storage, ddisk fs.Storage, fdriver contract.Driver, ddriver contract.Driver) Root {
 	return Root{
-		data:   ddisk,
-		disk:   fdisk,
-		driver: driver,
+		data:    ddisk,
+		disk:    fdisk,
+		driver:  fdriver,
+		ddriver: ddriver,
+		lock:    &sync.Mutex{},
+		forms:   jutils.NewCache[*Form](),
+		list:    jutils.NewCache[[]*Form](),
 	}
 }
 
+// Disk returns the storage of the forms
+func (root *Root) Disk() fs.Storage {
+	return root.disk
+}
+
+// Data returns the storage of the submissions
+func (root *Root) Data() fs.Storage {
+	return root.data
+}
+
+// Index parses every form into memory, Find and List are then
+// served from memory until Invalidate is called on changes.
+//
+// Returns:
+// - An error if a form cannot be parsed.
+func (root *Root) Index() error {
+	root.forms.Enable()
+	root.list.Enable()
+	_, err := root.List()
+	return err
+}
+
+// Invalidate drops the parsed forms after a change of the forms,
+// they are parsed again on their next use.
+func (root *Root) Invalidate() {
+	root.forms.Clear()
+	root.list.Clear()
+}
+
+// key returns the cache key of a form path with or without extension
+func (root *Root) key(ppath string) string {
+	return strings.TrimSuffix(path.Clean(ppath), "."+root.disk.Ext())
+}
+
 func (root *Root) Find(ppath string) (*Form, error) {
-	entry, err := root.disk.Find(path.Clean(ppath))
+	key := root.key(ppath)
+	if fm, ok := root.forms.Get(key); ok {
+		return fm, nil
+	}
+	generation := root.forms.Generation()
+
+	fm, err := root.find(path.Clean(ppath))
+	if err != nil {
+		return nil, err
+	}
+	root.forms.Set(generation, key, fm)
+	return fm, nil
+}
+
+func (root *Root) find(ppath string) (*Form, error) {
+	entry, err := root.disk.Find(ppath)
 	if err != nil {
 		return nil, err
 	}
@@ -43,18 +127,74 @@ func (root *Root) Find(ppath string) (*Form, error) {
 		return nil, err
 	}
 
-	frontmatter, body, err := root.driver.Parse(raw)
+	format := driver.Resolve(root.driver, raw)
+	frontmatter, body, err := format.Parse(raw)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Form{
-		meta:  *frontmatter,
-		body:  body,
-		entry: entry,
-		root:  root,
-	}, nil
+	// Form documents may store their submissions with
+	// a different driver than the data mount default
+	ddriver := root.ddriver
+	name, ok := (*frontmatter)["driver"].(string)
+	if ok && name != "" {
+		ddriver, err = driver.Find(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	fm := &Form{
+		meta:   *frontmatter,
+		body:   body,
+		entry:  entry,
+		root:   root,
+		format: format,
+		driver: ddriver,
+	}
 
+	switch fm.Collision() {
+	case COLLISION_FAIL, COLLISION_SUFFIX, COLLISION_OVERWRITE, COLLISION_MERGE:
+		return fm, nil
+	default:
+		return nil, fmt.Errorf("collision strategy not found: %s", fm.Collision())
+	}
+}
+
+// SetAction sets the base url forms post to, e.g a julien
+// instance serving the forms of a statically exported site
+func (root *Root) SetAction(url string) {
+	root.action = strings.TrimRight(url, "/")
+}
+
+// Action returns the url the form name posts to
+func (root *Root) Action(name string) string {
+	return root.action + "/" + strings.TrimLeft(name, "/")
+}
+
+func (root *Root) List() ([]*Form, error) {
+	if forms, ok := root.list.Get(""); ok {
+		return append(make([]*Form, 0, len(forms)), forms...), nil
+	}
+	generation := root.list.Generation()
+
+	forms := make([]*Form, 0)
+	entries, err := root.disk.List("")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsFile() || entry.IsIndex() {
+			continue
+		}
+		fm, err := root.Find(entry.Path())
+		if err != nil {
+			return nil, fmt.Errorf("form %s: %w", entry.Path(), err)
+		}
+		forms = append(forms, fm)
+	}
+	root.list.Set(generation, "", append(make([]*Form, 0, len(forms)), forms...))
+	return forms, nil
 }
 
 func (root *Root) Open(ppath string) *Form {
@@ -70,6 +210,7 @@ func (root *Root) Dump(ppath string, content []byte) (*Form, error) {
 	if err != nil {
 		return nil, err
 	}
+	root.Invalidate()
 	return root.Find(ppath)
 }
 
@@ -79,19 +220,70 @@ func (fm *Form) crate_entry_doc(entry *fs.Entry) (*Doc, error) {
 		return nil, err
 	}
 
-	frontmatter, body, err := fm.root.driver.Parse(raw)
+	ddriver := driver.Resolve(fm.driver, raw)
+	frontmatter, body, err := ddriver.Parse(raw)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Doc{
-		form:  fm,
-		meta:  *frontmatter,
-		body:  body,
-		entry: entry,
+		form:   fm,
+		meta:   *frontmatter,
+		body:   body,
+		entry:  entry,
+		driver: ddriver,
 	}, nil
 }
 
+// Migrate copies the submissions of every form from src into the data storage
+// replacing documents that already exist.
+//
+// Parameters:
+// - src: The storage to copy submissions from, usually the old data directory.
+//
+// Returns:
+// - The number of documents copied.
+// - An error if a form or document cannot be copied.
+func (root *Root) Migrate(src fs.Storage) (int, error) {
+	count := 0
+	forms, err := root.List()
+	if err != nil {
+		return count, err
+	}
+	for _, fm := range forms {
+		entries, err := src.List(fm.Name())
+		if err != nil {
+			if errors.Is(err, iofs.ErrNotExist) {
+				continue
+			}
+			return count, err
+		}
+		for _, entry := range entries {
+			if !entry.IsFile() {
+				continue
+			}
+			raw, err := entry.Read()
+			if err != nil {
+				return count, err
+			}
+			if err := root.data.Dump(entry.Path(), raw); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Action returns the url the form posts to
+func (fm *Form) Action() string {
+	return fm.root.Action(fm.Name())
+}
+
+func (fm *Form) Driver() contract.Driver {
+	return fm.driver
+}
+
 func (fm *Form) Name() string {
 	return strings.Trim(fm.entry.Name(), fm.Ext())
 }
@@ -174,7 +366,7 @@ func (form *Form) Fill(frontmatter map[string]interface{}) {
 }
 
 func (fm *Form) Dump() ([]byte, error) {
-	bytes, err := fm.root.driver.Dump(&fm.meta, fm.body)
+	bytes, err := fm.format.Dump(&fm.meta, fm.body)
 	if err != nil {
 		return nil, err
 	}
@@ -230,6 +422,130 @@ func (fm *Form) Compose(name string, content []byte) (*Doc, error) {
 	return fm.Find(name)
 }
 
+// Collision returns the strategy applied when a submission
+// name is already taken, suffix unless the form sets collision
+func (fm *Form) Collision() string {
+	return fm.GetString("collision", COLLISION_SUFFIX)
+}
+
+// Seperator returns the separator of the submission name parts
+func (fm *Form) Seperator() string {
+	return fm.GetString("seperator", "_")
+}
+
+// Next increments and returns the submission counter of the form,
+// the counter never goes back so numbers are not reused.
+//
+// Returns:
+// - The next submission number starting at 1.
+// - An error if the data storage has no counters or the counter cannot be updated.
+func (fm *Form) Next() (int64, error) {
+	counter, ok := fm.root.data.(fs.Counter)
+	if !ok {
+		return 0, fmt.Errorf("form %s: data storage cannot number submissions", fm.Name())
+	}
+	return counter.Next(fm.Name())
+}
+
+// Submit dumps frontmatter and body with the form driver and stores
+// them as a new submission document in a single write. When a document
+// named name already exists the form collision strategy decides whether
+// the submission fails, gets a suffixed name, overwrites or is merged
+// into the existing document.
+//
+// Parameters:
+// - name: The submission document name.
+// - frontmatter: The submitted values.
+// - body: The submission document body.
+//
+// Returns:
+// - The stored submission document, its name may differ from name.
+// - An error if name is not a single file name.
+// - An error wrapping fs.ErrExist if the name is taken and cannot be resolved.
+func (fm *Form) Submit(name string, frontmatter map[string]interface{}, body string) (*Doc, error) {
+	if name == "" || name == "." || name == ".." || name != path.Base(name) {
+		return nil, fmt.Errorf("invalid submission name: %q", name)
+	}
+
+	switch fm.Collision() {
+	case COLLISION_OVERWRITE:
+		content, err := fm.driver.Dump(&frontmatter, body)
+		if err != nil {
+			return nil, err
+		}
+		return fm.Compose(name, content)
+
+	case COLLISION_MERGE:
+		return fm.merge(name, frontmatter, body)
+
+	case COLLISION_FAIL:
+		return fm.create(name, frontmatter, body)
+
+	default:
+		for suffix := 1; suffix <= MAX_SUFFIX; suffix++ {
+			sname := name
+			if suffix > 1 {
+				sname = name + fm.Seperator() + strconv.Itoa(suffix)
+			}
+			doc, err := fm.create(sname, frontmatter, body)
+			if !errors.Is(err, iofs.ErrExist) {
+				return doc, err
+			}
+		}
+		return nil, fmt.Errorf("form %s: %s: %w", fm.Name(), name, iofs.ErrExist)
+	}
+}
+
+// create stores a new submission failing if name is taken
+func (fm *Form) create(name string, frontmatter map[string]interface{}, body string) (*Doc, error) {
+	content, err := fm.driver.Dump(&frontmatter, body)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := fm.root.data.Create(path.Join(fm.Name(), name), content)
+	if err != nil {
+		return nil, err
+	}
+	return fm.crate_entry_doc(entry)
+}
+
+// merge stores frontmatter over the values of the submission name,
+// keeping its body when body is empty
+func (fm *Form) merge(name string, frontmatter map[string]interface{}, body string) (*Doc, error) {
+	fm.root.lock.Lock()
+	defer fm.root.lock.Unlock()
+
+	doc, err := fm.Find(name)
+	if err != nil {
+		doc, err = fm.create(name, frontmatter, body)
+		if !errors.Is(err, iofs.ErrExist) {
+			return doc, err
+		}
+		// Created by another process meanwhile
+		doc, err = fm.Find(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	merged := make(map[string]interface{}, len(doc.meta)+len(frontmatter))
+	for key, value := range doc.meta {
+		merged[key] = value
+	}
+	for key, value := range frontmatter {
+		merged[key] = value
+	}
+	if body == "" {
+		body = doc.Body()
+	}
+
+	content, err := doc.driver.Dump(&merged, body)
+	if err != nil {
+		return nil, err
+	}
+	return fm.Compose(name, content)
+}
+
 func (fm *Form) Timestamp() time.Time {
 	return fm.entry.Timestamp()
 }
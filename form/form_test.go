@@ -0,0 +1,270 @@
+package form
+
+import (
+	iofs "io/fs"
+	"julien/driver"
+	"julien/fs"
+	"julien/limit"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func forms(collision string) *Form {
+	fdisk := fs.NewMemory("index", "md")
+	ddisk := fs.NewMemory("index", "md")
+	fdisk.Dump("contact", []byte("---\ntitle: Contact\ncollision: "+collision+"\n---\n"))
+	root := Init(fdisk, ddisk, &driver.Yaml{}, &driver.Yaml{})
+	fm, err := root.Find("contact")
+	if err != nil {
+		panic(err)
+	}
+	return fm
+}
+
+func TestSubmitSuffix(t *testing.T) {
+	fm := forms("suffix")
+
+	one, err := fm.Submit("bob", map[string]interface{}{"n": 1}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "bob", one.Name())
+
+	two, err := fm.Submit("bob", map[string]interface{}{"n": 2}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "bob_2", two.Name())
+
+	docs, err := fm.List()
+	assert.NoError(t, err)
+	assert.Len(t, docs, 2)
+	assert.Equal(t, 1, fm.Open("bob").Get("n"))
+}
+
+func TestSubmitFail(t *testing.T) {
+	fm := forms("fail")
+
+	_, err := fm.Submit("bob", map[string]interface{}{"n": 1}, "")
+	assert.NoError(t, err)
+
+	_, err = fm.Submit("bob", map[string]interface{}{"n": 2}, "")
+	assert.ErrorIs(t, err, iofs.ErrExist)
+	assert.Equal(t, 1, fm.Open("bob").Get("n"))
+}
+
+func TestSubmitOverwrite(t *testing.T) {
+	fm := forms("overwrite")
+
+	_, err := fm.Submit("bob", map[string]interface{}{"n": 1, "a": "x"}, "first")
+	assert.NoError(t, err)
+	_, err = fm.Submit("bob", map[string]interface{}{"n": 2}, "")
+	assert.NoError(t, err)
+
+	doc := fm.Open("bob")
+	assert.Equal(t, 2, doc.Get("n"))
+	assert.False(t, doc.Has("a"))
+	assert.Equal(t, "", doc.Body())
+}
+
+func TestSubmitMerge(t *testing.T) {
+	fm := forms("merge")
+
+	_, err := fm.Submit("bob", map[string]interface{}{"n": 1, "a": "x"}, "first")
+	assert.NoError(t, err)
+	_, err = fm.Submit("bob", map[string]interface{}{"n": 2}, "")
+	assert.NoError(t, err)
+
+	doc := fm.Open("bob")
+	assert.Equal(t, 2, doc.Get("n"))
+	assert.Equal(t, "x", doc.Get("a"))
+	assert.Equal(t, "first", doc.Body())
+}
+
+func TestCollisionNotFound(t *testing.T) {
+	fdisk := fs.NewMemory("index", "md")
+	fdisk.Dump("contact", []byte("---\ncollision: replace\n---\n"))
+	root := Init(fdisk, fs.NewMemory("index", "md"), &driver.Yaml{}, &driver.Yaml{})
+
+	_, err := root.Find("contact")
+	assert.Error(t, err)
+	_, err = root.List()
+	assert.Error(t, err)
+}
+
+func TestNext(t *testing.T) {
+	fm := forms("suffix")
+	for expected := int64(1); expected <= 2; expected++ {
+		seq, err := fm.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, expected, seq)
+	}
+}
+
+func TestSubmitInvalidName(t *testing.T) {
+	fm := forms("suffix")
+	for _, name := range []string{"", ".", "..", "../escape", "nested/name"} {
+		_, err := fm.Submit(name, map[string]interface{}{"n": 1}, "")
+		assert.Error(t, err, name)
+	}
+	docs, err := fm.List()
+	assert.ErrorIs(t, err, iofs.ErrNotExist)
+	assert.Len(t, docs, 0)
+}
+
+func TestIndex(t *testing.T) {
+	fdisk := fs.NewMemory("index", "md")
+	fdisk.Dump("contact", []byte("---\ntitle: Contact\n---\n"))
+	root := Init(fdisk, fs.NewMemory("index", "md"), &driver.Yaml{}, &driver.Yaml{})
+	assert.NoError(t, root.Index())
+
+	fdisk.Dump("contact", []byte("---\ntitle: Changed\n---\n"))
+	fdisk.Dump("signup", []byte("---\ntitle: Sign up\n---\n"))
+
+	fm, err := root.Find("contact")
+	assert.NoError(t, err)
+	assert.Equal(t, "Contact", fm.Get("title"))
+	forms, err := root.List()
+	assert.NoError(t, err)
+	assert.Len(t, forms, 1)
+
+	root.Invalidate()
+
+	fm, err = root.Find("contact")
+	assert.NoError(t, err)
+	assert.Equal(t, "Changed", fm.Get("title"))
+	forms, err = root.List()
+	assert.NoError(t, err)
+	assert.Len(t, forms, 2)
+}
+
+func TestFiles(t *testing.T) {
+	fdisk := fs.NewMemory("index", "md")
+	ddisk := fs.NewMemory("index", "md")
+	fdisk.Dump("apply", []byte("---\ntitle: Apply\nfiles:\n    cv:\n        required: true\n        max_size: 1KB\n        types: [application/pdf, image/*]\n    photos:\n        max_count: 2\n---\n"))
+	root := Init(fdisk, ddisk, &driver.Yaml{}, &driver.Yaml{})
+	fm, err := root.Find("apply")
+	assert.NoError(t, err)
+
+	fields, err := fm.FileFields()
+	assert.NoError(t, err)
+	cv, photos := fields["cv"], fields["photos"]
+	assert.Equal(t, int64(1024), cv.MaxSize)
+	assert.Equal(t, MAX_FILE_SIZE, photos.MaxSize)
+
+	pdf := []byte("%PDF-1.4\n%âãÏÓ\n")
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	text := []byte("just some text")
+	assert.Equal(t, []string{FILE_REQUIRED}, cv.Check(nil))
+	assert.Empty(t, cv.Check([]Upload{{Name: "cv.pdf", Content: pdf}}))
+	assert.Empty(t, cv.Check([]Upload{{Name: "me.png", Content: png}}))
+	assert.Equal(t, []string{FILE_TYPE}, cv.Check([]Upload{{Name: "cv.pdf", Content: text}}))
+	assert.Equal(t, []string{FILE_COUNT, FILE_SIZE}, cv.Check([]Upload{{Content: pdf}, {Content: append(pdf, make([]byte, 2048)...)}}))
+	assert.Empty(t, photos.Check(nil))
+
+	doc, err := fm.Submit("bob", map[string]interface{}{"name": "Bob"}, "")
+	assert.NoError(t, err)
+	attachments, err := doc.Attach("cv", []Upload{{Name: "../../My CV.pdf", Content: pdf}})
+	assert.NoError(t, err)
+	assert.NoError(t, doc.Save())
+	assert.Equal(t, "apply/bob.files/cv/1-My-CV.pdf", attachments[0].Path)
+	assert.Equal(t, "application/pdf", attachments[0].Type)
+
+	saved, err := fm.Find("bob")
+	assert.NoError(t, err)
+	records := saved.Get("cv").([]interface{})
+	record := records[0].(map[interface{}]interface{})
+	assert.Equal(t, "My CV.pdf", record["name"])
+	assert.Equal(t, attachments[0].SHA256, record["sha256"])
+
+	content, err := fm.File(attachments[0].Path)
+	assert.NoError(t, err)
+	assert.Equal(t, pdf, content)
+	_, err = fm.File("apply/bob.md")
+	assert.Error(t, err)
+	_, err = fm.File("other/bob.files/cv/1-My-CV.pdf")
+	assert.Error(t, err)
+
+	// Submissions are listed without their files
+	docs, err := fm.List()
+	assert.NoError(t, err)
+	assert.Len(t, docs, 1)
+	assert.Equal(t, "noext.bin", FileName("noext", ""))
+	assert.Equal(t, "photo.png", FileName("photo", ".png"))
+}
+
+func TestNotify(t *testing.T) {
+	fdisk := fs.NewMemory("index", "md")
+	ddisk := fs.NewMemory("index", "md")
+	fdisk.Dump("contact", []byte("---\ntitle: Contact\nnotify:\n    to: [Sales <sales@example.com>, ops@example.com]\n    template: mail/contact\nreply:\n    subject: Thanks\n    field: mail\n    rate_limit:\n        limit: 2\nschema:\n    mail: required,email\n---\n"))
+	fdisk.Dump("unchecked", []byte("---\ntitle: Unchecked\nreply:\n    subject: Thanks\nschema:\n    email: required\n---\n"))
+	fdisk.Dump("silent", []byte("---\ntitle: Silent\n---\n"))
+	fdisk.Dump("broken", []byte("---\ntitle: Broken\nnotify:\n    to: nobody\n---\n"))
+	root := Init(fdisk, ddisk, &driver.Yaml{}, &driver.Yaml{})
+
+	fm, _ := root.Find("contact")
+	notify, err := fm.Notify()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Sales <sales@example.com>", "ops@example.com"}, notify.To)
+	assert.Equal(t, "Contact", notify.Subject)
+	assert.Equal(t, "mail/contact", notify.Template)
+	reply, err := fm.Reply()
+	assert.NoError(t, err)
+	assert.Equal(t, "Thanks", reply.Subject)
+	assert.Equal(t, "mail", reply.Field)
+	assert.Equal(t, limit.Rule{Limit: 2, Window: limit.WINDOW, Key: "@mail"}, reply.Limit)
+
+	// Replies only go to addresses the schema validates
+	unchecked, _ := root.Find("unchecked")
+	_, err = unchecked.Reply()
+	assert.Error(t, err)
+
+	silent, _ := root.Find("silent")
+	notify, err = silent.Notify()
+	assert.NoError(t, err)
+	assert.Nil(t, notify)
+	broken, _ := root.Find("broken")
+	_, err = broken.Notify()
+	assert.Error(t, err)
+
+	doc, err := fm.Submit("bob", map[string]interface{}{"mail": "Bob <bob@mail.com>", "name": "bob"}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "bob@mail.com", doc.Address("mail"))
+	assert.Equal(t, "", doc.Address("name"))
+}
+
+func TestWebhooks(t *testing.T) {
+	fdisk := fs.NewMemory("index", "md")
+	ddisk := fs.NewMemory("index", "md")
+	fdisk.Dump("contact", []byte("---\ntitle: Contact\nwebhooks:\n    crm:\n        url: https://crm.example.com/leads\n        secret: s3cret\n        headers:\n            X-Token: abc\n    chat:\n        url: http://chat.example.com/hook\n        method: put\n        template: hooks/chat\n---\n"))
+	fdisk.Dump("broken", []byte("---\ntitle: Broken\nwebhooks:\n    crm:\n        url: ftp://crm.example.com\n---\n"))
+	root := Init(fdisk, ddisk, &driver.Yaml{}, &driver.Yaml{})
+
+	fm, _ := root.Find("contact")
+	hooks, err := fm.Webhooks()
+	assert.NoError(t, err)
+	assert.Len(t, hooks, 2)
+	assert.Equal(t, "POST", hooks["crm"].Method)
+	assert.Equal(t, "s3cret", hooks["crm"].Secret)
+	assert.Equal(t, map[string]string{"X-Token": "abc"}, hooks["crm"].Headers)
+	assert.Equal(t, "PUT", hooks["chat"].Method)
+	assert.Equal(t, "hooks/chat", hooks["chat"].Template)
+
+	broken, _ := root.Find("broken")
+	_, err = broken.Webhooks()
+	assert.Error(t, err)
+}
+
+func TestQuarantine(t *testing.T) {
+	fm := forms("suffix")
+	ppath, err := fm.Quarantine("honeypot", map[string]interface{}{"name": "bot"}, "buy now")
+	assert.NoError(t, err)
+	content, err := fm.root.data.Read(ppath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "reason: honeypot")
+	assert.Contains(t, string(content), "buy now")
+
+	// Quarantined submissions are not submissions of the form
+	_, err = fm.Submit("bob", map[string]interface{}{"name": "bob"}, "")
+	assert.NoError(t, err)
+	docs, err := fm.List()
+	assert.NoError(t, err)
+	assert.Len(t, docs, 1)
+}
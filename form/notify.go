@@ -0,0 +1,131 @@
+package form
+
+import (
+	"fmt"
+	"julien/limit"
+	netmail "net/mail"
+	"strings"
+)
+
+// REPLY_FIELD is the field of the submitter address unless configured
+const REPLY_FIELD string = "email"
+
+// REPLY_LIMIT is the number of auto replies an address gets per
+// window unless configured
+const REPLY_LIMIT int = 1
+
+// Mail is a message sent on a submission, declared under the notify
+// key of a form for its recipients or the reply key for the submitter
+type Mail struct {
+	To       []string // Recipients of notifications
+	Subject  string
+	Template string     // Template view rendered with the Post, the submission dump if empty
+	Field    string     // Field of the submitter address
+	Limit    limit.Rule // Auto replies per submitter address
+}
+
+// Notify reads the notify key of the form e.g
+// notify: {to: [sales@example.com], subject: New contact, template: mail/contact-us}
+//
+// Returns:
+// - The notification, nil if the form has no notify key.
+// - An error for a malformed notify key.
+func (fm *Form) Notify() (*Mail, error) {
+	mail, err := fm.mail("notify")
+	if err != nil || mail == nil {
+		return mail, err
+	}
+	if len(mail.To) == 0 {
+		return nil, fmt.Errorf("form %s: notify has no recipients", fm.Name())
+	}
+	return mail, nil
+}
+
+// Reply reads the reply key of the form, the auto reply sent to the
+// address of the submitter e.g reply: {subject: Thanks, field: email}.
+// The field must be validated as an email by the schema of the form
+// and an address gets REPLY_LIMIT replies per hour unless the
+// rate_limit of the reply key says otherwise.
+//
+// Returns:
+// - The auto reply, nil if the form has no reply key.
+// - An error for a malformed reply key or a field not validated as an email.
+func (fm *Form) Reply() (*Mail, error) {
+	mail, err := fm.mail("reply")
+	if err != nil || mail == nil {
+		return mail, err
+	}
+	if !fm.validates(mail.Field, "email") {
+		return nil, fmt.Errorf("form %s: reply field %s is not validated as an email by the schema", fm.Name(), mail.Field)
+	}
+	spec := fm.Get("reply").(map[interface{}]interface{})
+	base := limit.Rule{Limit: REPLY_LIMIT, Window: limit.WINDOW, Key: "@" + mail.Field}
+	if mail.Limit, err = limit.Parse(spec["rate_limit"], base); err != nil {
+		return nil, fmt.Errorf("form %s: reply %w", fm.Name(), err)
+	}
+	return mail, nil
+}
+
+// validates reports whether the schema of the form
+// checks a field with a validator tag e.g email
+func (fm *Form) validates(field string, tag string) bool {
+	schema, ok := fm.Get("schema").(map[interface{}]interface{})
+	if !ok {
+		return false
+	}
+	rules, ok := schema[field].(string)
+	if !ok {
+		return false
+	}
+	for _, rule := range strings.Split(rules, ",") {
+		if strings.TrimSpace(rule) == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func (fm *Form) mail(key string) (*Mail, error) {
+	value := fm.Get(key)
+	if value == nil {
+		return nil, nil
+	}
+	spec, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("form %s: %s must be a map", fm.Name(), key)
+	}
+	mail := &Mail{To: make([]string, 0), Subject: fm.GetString("title"), Field: REPLY_FIELD}
+	switch to := spec["to"].(type) {
+	case string:
+		mail.To = append(mail.To, to)
+	case []interface{}:
+		for _, address := range to {
+			mail.To = append(mail.To, fmt.Sprint(address))
+		}
+	}
+	for _, address := range mail.To {
+		if _, err := netmail.ParseAddress(address); err != nil {
+			return nil, fmt.Errorf("form %s: %s to %q: %w", fm.Name(), key, address, err)
+		}
+	}
+	if subject, ok := spec["subject"].(string); ok {
+		mail.Subject = subject
+	}
+	if template, ok := spec["template"].(string); ok {
+		mail.Template = template
+	}
+	if field, ok := spec["field"].(string); ok && field != "" {
+		mail.Field = field
+	}
+	return mail, nil
+}
+
+// Address returns the valid address of the submitter in the
+// field of a mail, empty if the field is not an address
+func (doc *Doc) Address(field string) string {
+	address, err := netmail.ParseAddress(doc.GetString(field))
+	if err != nil {
+		return ""
+	}
+	return address.Address
+}
@@ -0,0 +1,40 @@
+package form
+
+import (
+	"path"
+	"strconv"
+	"time"
+)
+
+// QUARANTINE_DIR is the directory of the rejected submissions in the data mount
+const QUARANTINE_DIR string = ".quarantine"
+
+// Quarantine stores a rejected submission apart from the submissions
+// of the form under .quarantine/<form>/<nanoseconds>, the reason is
+// recorded in its frontmatter.
+//
+// Parameters:
+// - reason: Why the submission was rejected.
+// - frontmatter: The submitted values.
+// - body: The submission document body.
+//
+// Returns:
+// - The path of the quarantined submission in the data mount.
+// - An error if it cannot be stored.
+func (fm *Form) Quarantine(reason string, frontmatter map[string]interface{}, body string) (string, error) {
+	meta := make(map[string]interface{}, len(frontmatter)+1)
+	for key, value := range frontmatter {
+		meta[key] = value
+	}
+	meta["reason"] = reason
+	content, err := fm.driver.Dump(&meta, body)
+	if err != nil {
+		return "", err
+	}
+	name := path.Join(QUARANTINE_DIR, fm.Name(), strconv.FormatInt(time.Now().UnixNano(), 10))
+	entry, err := fm.root.data.Create(name, content)
+	if err != nil {
+		return "", err
+	}
+	return entry.Path(), nil
+}
@@ -0,0 +1,71 @@
+package form
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Webhook is a request sent on a submission, declared under the
+// webhooks key of a form by name
+type Webhook struct {
+	Name     string
+	URL      string
+	Method   string // Defaults to POST
+	Secret   string // Signs the body when set
+	Template string // Template view of the body, the submission as json if empty
+	Headers  map[string]string
+}
+
+// Webhooks reads the webhooks key of the form e.g
+// webhooks: {crm: {url: https://crm.example.com/leads, secret: s3cret, template: hooks/crm}}
+//
+// Returns:
+// - The webhooks by name, empty if the form has no webhooks key.
+// - An error for a malformed webhooks key.
+func (fm *Form) Webhooks() (map[string]Webhook, error) {
+	hooks := make(map[string]Webhook)
+	value := fm.Get("webhooks")
+	if value == nil {
+		return hooks, nil
+	}
+	specs, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("form %s: webhooks must be a map", fm.Name())
+	}
+	for key, value := range specs {
+		name := fmt.Sprint(key)
+		spec, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return nil, fmt.Errorf("form %s: webhook %s must be a map", fm.Name(), name)
+		}
+		hook := Webhook{Name: name, Method: http.MethodPost, Headers: make(map[string]string)}
+		hook.URL, _ = spec["url"].(string)
+		link, err := url.Parse(hook.URL)
+		if err != nil || (link.Scheme != "http" && link.Scheme != "https") || link.Host == "" {
+			return nil, fmt.Errorf("form %s: webhook %s: invalid url %q", fm.Name(), name, hook.URL)
+		}
+		if method, ok := spec["method"].(string); ok && method != "" {
+			hook.Method = strings.ToUpper(method)
+		}
+		hook.Secret, _ = spec["secret"].(string)
+		hook.Template, _ = spec["template"].(string)
+		if headers, ok := spec["headers"].(map[interface{}]interface{}); ok {
+			for key, value := range headers {
+				hook.Headers[fmt.Sprint(key)] = fmt.Sprint(value)
+			}
+		}
+		hooks[name] = hook
+	}
+	return hooks, nil
+}
+
+// Frontmatter returns a copy of the frontmatter of the submission
+func (doc *Doc) Frontmatter() map[string]interface{} {
+	frontmatter := make(map[string]interface{}, len(doc.meta))
+	for key, value := range doc.meta {
+		frontmatter[key] = value
+	}
+	return frontmatter
+}
@@ -0,0 +1,160 @@
+package fs
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TEMP_EXT is the extension of the temporary files written by WriteFile.
+const TEMP_EXT string = ".tmp"
+
+// IsTemp reports whether filename is a temporary file left by WriteFile.
+//
+// Parameters:
+// - filename: The base name of the file.
+//
+// Returns:
+// - true if the file is a temporary file, false otherwise.
+func IsTemp(filename string) bool {
+	return strings.HasPrefix(filename, ".") && strings.HasSuffix(filename, TEMP_EXT)
+}
+
+// WriteFile atomically replaces the content of a file. The content is
+// written to a temporary file in the same directory, fsynced and then
+// renamed over fpath so readers see either the old or the new content
+// and never a partial write.
+//
+// Parameters:
+// - fpath: The path of the file to write.
+// - content: The byte slice to write.
+// - mode: The file mode permissions of the file.
+// - syncdir: Whether to fsync the parent directory so the rename survives a crash.
+//
+// Returns:
+// - An error if the file cannot be written, fpath is left untouched.
+func WriteFile(fpath string, content []byte, mode fs.FileMode, syncdir bool) (err error) {
+	dir, base := filepath.Split(fpath)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+base+".*"+TEMP_EXT)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(content); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(mode); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), fpath); err != nil {
+		return err
+	}
+
+	if syncdir {
+		return SyncDir(dir)
+	}
+	return nil
+}
+
+// CreateFile atomically creates a file that must not exist yet. The
+// content is written to a temporary file which is then hard linked
+// to fpath, so the file appears complete and of two concurrent
+// creates only one succeeds.
+//
+// Parameters:
+// - fpath: The path of the file to create.
+// - content: The byte slice to write.
+// - mode: The file mode permissions of the file.
+// - syncdir: Whether to fsync the parent directory so the file survives a crash.
+//
+// Returns:
+// - An error wrapping fs.ErrExist if the file already exists.
+func CreateFile(fpath string, content []byte, mode fs.FileMode, syncdir bool) error {
+	dir, base := filepath.Split(fpath)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+base+".*"+TEMP_EXT)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	if err = os.Link(tmp.Name(), fpath); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return &fs.PathError{Op: "create", Path: fpath, Err: fs.ErrExist}
+		}
+		// File systems without hard links
+		// fall back to an exclusive open
+		file, oerr := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+		if oerr != nil {
+			return oerr
+		}
+		if _, err = file.Write(content); err == nil {
+			err = file.Sync()
+		}
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(fpath)
+			return err
+		}
+	}
+
+	if syncdir {
+		return SyncDir(dir)
+	}
+	return nil
+}
+
+// SyncDir fsyncs a directory so entries renamed or created in it are durable.
+//
+// Parameters:
+// - dir: The path of the directory.
+//
+// Returns:
+// - An error if the directory cannot be opened or synced.
+func SyncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
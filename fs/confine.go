@@ -0,0 +1,88 @@
+package fs
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrOutside is returned for paths resolving outside of a mount root.
+var ErrOutside = errors.New("path outside of mount root")
+
+// within reports whether target is root or below root.
+func within(root string, target string) bool {
+	rel, err := filepath.Rel(root, target)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Confine resolves a path relative to a mount root. The path is first
+// normalised so ".." elements cannot climb above the root, then symlinks
+// of the deepest existing part of the path are resolved and the path is
+// rejected if they lead outside of the root. Paths that do not exist yet
+// are accepted so files can be created.
+//
+// Parameters:
+// - root: The mount root directory.
+// - ppath: The slash separated path relative to root.
+//
+// Returns:
+// - The path joined onto root.
+// - An error wrapping ErrOutside if the path resolves outside of root.
+func Confine(root string, ppath string) (string, error) {
+	root = filepath.Clean(root)
+	full := filepath.Join(root, filepath.FromSlash(path.Clean("/"+ppath)))
+
+	realroot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			// Nothing below a missing root can be a symlink
+			return full, nil
+		}
+		return "", err
+	}
+
+	for existing := full; within(root, existing); existing = filepath.Dir(existing) {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			if !within(realroot, resolved) {
+				return "", &fs.PathError{Op: "confine", Path: ppath, Err: ErrOutside}
+			}
+			return full, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		if existing == root {
+			break
+		}
+	}
+	return full, nil
+}
+
+// CleanName makes a user supplied value safe to use as a single file
+// name. Path separators are replaced with dashes, control characters
+// are dropped and leading dots are trimmed so the name can neither
+// climb directories nor create hidden files.
+//
+// Parameters:
+// - name: The value to clean.
+//
+// Returns:
+// - The cleaned name, possibly empty.
+func CleanName(name string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		switch {
+		case r == '/' || r == '\\':
+			return '-'
+		case r < 0x20 || r == 0x7f:
+			return -1
+		}
+		return r
+	}, name)
+	return strings.TrimLeft(strings.TrimSpace(cleaned), ".")
+}
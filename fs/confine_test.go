@@ -0,0 +1,82 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfine(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	os.WriteFile(filepath.Join(outside, "secret.md"), []byte("secret"), 0644)
+	os.Mkdir(filepath.Join(root, "posts"), 0755)
+	os.WriteFile(filepath.Join(root, "posts", "one.md"), []byte("one"), 0644)
+	os.Symlink(outside, filepath.Join(root, "escape"))
+	os.Symlink(filepath.Join(outside, "secret.md"), filepath.Join(root, "secret.md"))
+	os.Symlink(filepath.Join(root, "posts"), filepath.Join(root, "alias"))
+
+	// Dot dot elements cannot climb above the root
+	full, err := Confine(root, "../../etc/passwd")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "etc/passwd"), full)
+
+	full, err = Confine(root, "posts/../posts/one.md")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "posts/one.md"), full)
+
+	// Missing files can be created
+	_, err = Confine(root, "posts/new/two.md")
+	assert.NoError(t, err)
+
+	// Symlinks inside the root are followed
+	_, err = Confine(root, "alias/one.md")
+	assert.NoError(t, err)
+
+	// Symlinks leading outside of the root are rejected
+	_, err = Confine(root, "escape/secret.md")
+	assert.ErrorIs(t, err, ErrOutside)
+	_, err = Confine(root, "escape/new.md")
+	assert.ErrorIs(t, err, ErrOutside)
+	_, err = Confine(root, "secret.md")
+	assert.ErrorIs(t, err, ErrOutside)
+}
+
+func TestDiskConfined(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	os.WriteFile(filepath.Join(outside, "secret.md"), []byte("secret"), 0644)
+	os.Symlink(outside, filepath.Join(root, "escape"))
+
+	disk := Mount(root, "index", "md")
+
+	_, err := disk.Read("escape/secret.md")
+	assert.ErrorIs(t, err, ErrOutside)
+	_, err = disk.Find("escape/secret")
+	assert.ErrorIs(t, err, ErrOutside)
+	assert.ErrorIs(t, disk.Dump("escape/secret", []byte("owned")), ErrOutside)
+	assert.ErrorIs(t, disk.Append("escape/secret", []byte("owned")), ErrOutside)
+	assert.ErrorIs(t, disk.Remove("escape/secret.md"), ErrOutside)
+	_, err = disk.Create("escape/other", []byte("owned"))
+	assert.ErrorIs(t, err, ErrOutside)
+
+	content, err := os.ReadFile(filepath.Join(outside, "secret.md"))
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", string(content))
+
+	// Climbing paths are kept inside the root
+	assert.NoError(t, disk.Dump("../../climb", []byte("inside")))
+	_, err = os.Stat(filepath.Join(root, "climb.md"))
+	assert.NoError(t, err)
+}
+
+func TestCleanName(t *testing.T) {
+	assert.Equal(t, "-..-etc-passwd", CleanName("../../etc/passwd"))
+	assert.Equal(t, "bob@julien.dev", CleanName("bob@julien.dev"))
+	assert.Equal(t, "a-b-c", CleanName("a/b\\c"))
+	assert.Equal(t, "hidden", CleanName("..hidden"))
+	assert.Equal(t, "line", CleanName("li\nne"))
+	assert.Equal(t, "", CleanName(".."))
+}
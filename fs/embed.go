@@ -0,0 +1,115 @@
+package fs
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// ErrReadOnly is returned by writes to read only storages.
+var ErrReadOnly = errors.New("read only storage")
+
+// Embed represents a read only storage backed by any fs.FS such as an
+// embed.FS compiled into the binary.
+type Embed struct {
+	fs    fs.FS  // The file system interface.
+	root  string // The root directory within the file system.
+	ext   string // Default file extension to use.
+	index string // Default index file name.
+}
+
+// MountFS creates a new read only storage mounted at root within fsys.
+//
+// Parameters:
+// - fsys: The file system to mount, e.g an embed.FS.
+// - root: The directory within fsys to mount.
+// - index: The default index file name.
+// - ext: The default file extension to use.
+//
+// Returns:
+// - A pointer to the newly created Embed storage.
+// - An error if root is not a valid path within fsys.
+func MountFS(fsys fs.FS, root string, index string, ext string) (*Embed, error) {
+	sub, err := fs.Sub(fsys, clean(root))
+	if err != nil {
+		return nil, err
+	}
+	return &Embed{
+		fs:    sub,
+		root:  clean(root),
+		ext:   ext,
+		index: index,
+	}, nil
+}
+
+func (disk *Embed) Root() string {
+	return disk.root
+}
+
+func (disk *Embed) Ext() string {
+	return disk.ext
+}
+
+func (disk *Embed) Index() string {
+	return disk.index
+}
+
+func (disk *Embed) IName() string {
+	return disk.index + "." + disk.ext
+}
+
+// FS returns the file system rooted at the storage root.
+func (disk *Embed) FS() fs.FS {
+	return disk.fs
+}
+
+// List lists all entries in the specified directory.
+//
+// Parameters:
+// - rpath: The relative path of the directory to list.
+//
+// Returns:
+// - A slice of pointers to Entry objects.
+// - An error if the directory cannot be read.
+func (disk *Embed) List(rpath string) ([]*Entry, error) {
+	return list(disk, disk.fs, rpath)
+}
+
+// Find locates a file or directory within the storage.
+//
+// Parameters:
+// - filepath: The relative path to the file or directory.
+//
+// Returns:
+// - A pointer to the Entry if found.
+// - An error if the file or directory cannot be found.
+func (disk *Embed) Find(filepath string) (*Entry, error) {
+	return find(disk, disk.fs, filepath)
+}
+
+// Read reads the content of a file.
+//
+// Parameters:
+// - filepath: The relative path to the file to be read.
+//
+// Returns:
+// - The content of the file as a byte slice.
+// - An error if the file cannot be read.
+func (disk *Embed) Read(filepath string) ([]byte, error) {
+	return fs.ReadFile(disk.fs, clean(filepath))
+}
+
+func (disk *Embed) Dump(ppath string, _ []byte) error {
+	return &fs.PathError{Op: "dump", Path: ppath, Err: ErrReadOnly}
+}
+
+func (disk *Embed) Append(ppath string, _ []byte) error {
+	return &fs.PathError{Op: "append", Path: ppath, Err: ErrReadOnly}
+}
+
+func (disk *Embed) Create(ppath string, _ []byte) (*Entry, error) {
+	return nil, &fs.PathError{Op: "create", Path: ppath, Err: ErrReadOnly}
+}
+
+func (disk *Embed) Remove(filepath string) error {
+	return &fs.PathError{Op: "remove", Path: filepath, Err: ErrReadOnly}
+}
@@ -0,0 +1,37 @@
+package fs
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMountFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"site/content/index.md":          {Data: []byte("home")},
+		"site/content/articles/index.md": {Data: []byte("articles")},
+		"site/content/articles/one.md":   {Data: []byte("one")},
+		"site/content/articles/logo.png": {Data: []byte("png")},
+	}
+
+	disk, err := MountFS(fsys, "site/content", "index", "md")
+	assert.NoError(t, err)
+
+	entry, err := disk.Find("/articles/one")
+	assert.NoError(t, err)
+	content, err := entry.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, "one", string(content))
+
+	entries, err := disk.List("articles")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	// Writes are rejected
+	err = disk.Dump("articles/two", []byte("two"))
+	assert.True(t, errors.Is(err, ErrReadOnly))
+	_, err = disk.Create("articles/two", []byte("two"))
+	assert.True(t, errors.Is(err, ErrReadOnly))
+}
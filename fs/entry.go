@@ -13,7 +13,7 @@ type Entry struct {
 	path    string      // Full path to the entry.
 	name    string      // Base name of the entry.
 	is_file bool        // Whether the entry is a file.
-	disk    *Disk       // Reference to the Disk this entry belongs to.
+	disk    Storage     // Reference to the Storage this entry belongs to.
 	info    os.FileInfo // File information (size, permissions, etc.).
 }
 
@@ -29,15 +29,11 @@ func (entry *Entry) IsIndexed() bool {
 	if entry.IsFile() {
 		return true
 	}
-	file, err := entry.disk.fs.Open(entry.IndexPath())
+	index, err := entry.disk.Find(entry.IndexPath())
 	if err != nil {
 		return false
 	}
-	info, err := file.Stat()
-	if err != nil {
-		return false
-	}
-	return !info.IsDir()
+	return index.IsFile()
 }
 
 func (entry *Entry) HasExt(ext string) bool {
@@ -133,7 +129,7 @@ func (entry *Entry) IndexPath() string {
 	if entry.IsFile() {
 		return entry.Path()
 	}
-	return path.Clean(path.Join(entry.Path(), entry.disk.index+"."+entry.disk.ext))
+	return path.Clean(path.Join(entry.Path(), entry.disk.IName()))
 }
 
 // Fullpath returns the absolute path of the entry in the Disk.
@@ -144,7 +140,7 @@ func (entry *Entry) IndexPath() string {
 // Returns:
 // - The absolute path as a string.
 func (entry *Entry) Datapath() string {
-	return path.Clean(entry.disk.root + "/" + entry.IndexPath())
+	return path.Clean(entry.disk.Root() + "/" + entry.IndexPath())
 }
 
 // Read reads the content of the file represented by the entry.
@@ -156,7 +152,7 @@ func (entry *Entry) Read() ([]byte, error) {
 	if !entry.IsIndexed() {
 		return nil, fmt.Errorf("index not found at: %s", entry.IndexPath())
 	}
-	content, err := os.ReadFile(entry.Datapath())
+	content, err := entry.disk.Read(entry.IndexPath())
 	if err != nil {
 		return nil, err
 	}
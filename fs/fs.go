@@ -1,11 +1,14 @@
 package fs
 
 import (
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
 	"path"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/gofiber/fiber/v2/log"
 )
@@ -18,6 +21,8 @@ type Disk struct {
 	index string      // Default index file name.
 	fmode fs.FileMode // File mode permissions for files.
 	dmode fs.FileMode // File mode permissions for directories.
+	fsync bool        // Whether to fsync parent directories after writes.
+	lock  sync.Mutex  // Guards counters.
 }
 
 // HasExt checks if the given filename has the specified extension.
@@ -82,28 +87,6 @@ func Mount(root string, index string, ext string) *Disk {
 	}
 }
 
-// create_entry creates a new Entry from the given FileInfo and path.
-//
-// Parameters:
-// - entry: The FileInfo object containing details about the file or directory.
-// - fpath: The full path to the entry.
-//
-// Returns:
-// - A new Entry instance representing the file or directory.
-func (d *Disk) create_entry(entry fs.FileInfo, fpath string) Entry {
-	filename := path.Base(fpath)
-	fname, ext := GetNameParts(filename)
-	info := entry
-	return Entry{
-		ext:     ext,
-		info:    info,
-		path:    fpath,
-		name:    fname,
-		is_file: !entry.IsDir(),
-		disk:    d,
-	}
-}
-
 // Root returns the root directory of the Disk.
 //
 // Returns:
@@ -152,6 +135,27 @@ func (disk *Disk) Dmode() fs.FileMode {
 	return disk.dmode
 }
 
+// Fsync sets whether parent directories are fsynced after a file is
+// written so renames survive a crash, at the cost of slower writes.
+//
+// Parameters:
+// - fsync: Whether to fsync parent directories.
+//
+// Returns:
+// - The Disk for chaining.
+func (disk *Disk) Fsync(fsync bool) *Disk {
+	disk.fsync = fsync
+	return disk
+}
+
+// FS returns the file system used for lookups.
+//
+// Returns:
+// - The fs.FS rooted at the disk root.
+func (disk *Disk) FS() fs.FS {
+	return disk.fs
+}
+
 // List lists all entries in the specified directory.
 //
 // Parameters:
@@ -161,33 +165,10 @@ func (disk *Disk) Dmode() fs.FileMode {
 // - A slice of pointers to Entry objects representing the files and directories in the specified path.
 // - An error if the directory cannot be read.
 func (disk *Disk) List(rpath string) ([]*Entry, error) {
-	dirpath := path.Join(disk.root, rpath)
-	dentries := make([]*Entry, 0)
-
-	entries, err := os.ReadDir(dirpath)
-	if err != nil {
-		return dentries, err
+	if _, err := disk.Resolve(rpath); err != nil {
+		return make([]*Entry, 0), err
 	}
-
-	for _, entry := range entries {
-		if entry.IsDir() {
-			fullpath := path.Join(dirpath, entry.Name())
-			indexpath := path.Join(fullpath, disk.IName())
-			_, err := os.Stat(path.Join(indexpath))
-			if err != nil {
-				continue
-			}
-
-		} else if !entry.Type().IsRegular() || !HasExt(entry.Name(), disk.ext) {
-			continue
-		}
-		info, _ := entry.Info()
-		ppath := path.Join(rpath, entry.Name())
-		dentry := disk.create_entry(info, ppath)
-		dentries = append(dentries, &dentry)
-	}
-
-	return dentries, nil
+	return list(disk, disk.fs, rpath)
 }
 
 // Find locates a file or directory within the Disk.
@@ -199,69 +180,23 @@ func (disk *Disk) List(rpath string) ([]*Entry, error) {
 // - A pointer to the Entry if found.
 // - An error if the file or directory cannot be found.
 func (disk *Disk) Find(filepath string) (*Entry, error) {
-	filepath = path.Clean(filepath)
-	filepath = strings.TrimLeft(filepath, "/")
-	filepath = strings.TrimLeft(filepath, `\`)
-	if filepath == "/" || filepath == `\` {
-		filepath = "."
-	}
-	index_path := filepath
-
-	_, ext := GetNameParts(filepath)
-	fileinfo, err := fs.Stat(disk.fs, filepath)
-
-	// Check if file exist
-	if err != nil {
-		// File with ext not found
-		if ext != "" {
-			return nil, err
-		}
-
-		// Append disk extension  and try again
-		index_path = filepath + "." + disk.ext
-
-		fileinfo, err = fs.Stat(disk.fs, index_path)
-		if err != nil {
-			return nil, err
-		}
-		// Reject directory with extension
-		// not something i feel like will be usefull
-		if fileinfo.IsDir() {
-			return nil, fmt.Errorf("index file not found: %s", filepath)
-		}
-
-		// Found file by appending ext
-		filepath = index_path
-	}
-
-	if fileinfo.IsDir() {
-		// Check dir for index file
-		if filepath == "." {
-			index_path = disk.index + "." + disk.ext
-		} else {
-			index_path = path.Join(filepath, disk.index+"."+disk.ext)
-		}
-		_, err := fs.Stat(disk.fs, index_path)
-
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	file, err := disk.fs.Open(filepath)
-	if err != nil {
+	if _, err := disk.Resolve(filepath); err != nil {
 		return nil, err
 	}
-	defer file.Close()
-
-	info, err := file.Stat()
-	if err != nil {
-		return nil, err
-	}
-
-	dentry := disk.create_entry(info, filepath)
+	return find(disk, disk.fs, filepath)
+}
 
-	return &dentry, nil
+// Resolve returns the path of a file of the Disk on the os file system,
+// confined to the disk root.
+//
+// Parameters:
+// - ppath: The relative path to the file or directory.
+//
+// Returns:
+// - The path joined onto the disk root.
+// - An error wrapping ErrOutside if the path resolves outside of the root.
+func (disk *Disk) Resolve(ppath string) (string, error) {
+	return Confine(disk.root, ppath)
 }
 
 // Read reads the content of a file in the Disk.
@@ -273,7 +208,10 @@ func (disk *Disk) Find(filepath string) (*Entry, error) {
 // - The content of the file as a byte slice.
 // - An error if the file cannot be read.
 func (disk *Disk) Read(filepath string) ([]byte, error) {
-	fullpath := path.Join(disk.root, filepath)
+	fullpath, err := disk.Resolve(filepath)
+	if err != nil {
+		return nil, err
+	}
 	content, err := os.ReadFile(fullpath)
 	if err != nil {
 		return nil, err
@@ -281,7 +219,7 @@ func (disk *Disk) Read(filepath string) ([]byte, error) {
 	return content, nil
 }
 
-// Write writes content to a file in the Disk.
+// Write atomically writes content to a file in the Disk.
 //
 // Parameters:
 // - filepath: The relative path to the file to be written.
@@ -290,12 +228,11 @@ func (disk *Disk) Read(filepath string) ([]byte, error) {
 // Returns:
 // - An error if the file cannot be written.
 func (disk *Disk) Write(filepath string, content []byte) error {
-	fullpath := path.Join(disk.root, filepath)
-	err := os.WriteFile(fullpath, content, disk.fmode)
+	fullpath, err := disk.Resolve(filepath)
 	if err != nil {
 		return err
 	}
-	return nil
+	return WriteFile(fullpath, content, disk.fmode, disk.fsync)
 }
 
 // Remove deletes a file or directory from the Disk.
@@ -306,52 +243,61 @@ func (disk *Disk) Write(filepath string, content []byte) error {
 // Returns:
 // - An error if the file or directory cannot be deleted.
 func (disk *Disk) Remove(filepath string) error {
-	fullpath := path.Join(disk.root, filepath)
-	err := os.RemoveAll(fullpath)
+	fullpath, err := disk.Resolve(filepath)
+	if err != nil {
+		return err
+	}
+	err = os.RemoveAll(fullpath)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-// Dump dumps file content to the Disk replacing original content
-// if file already exist and is not empty.
-//
-// Parameters:
-// - filepath: The relative path to the file to be appended to.
-// - content: The byte slice to append to the file.
-//
-// Returns:
-// - An error if the file contents cannot be dumped.
-func (disk *Disk) Dump(ppath string, content []byte) error {
-	cpath := path.Clean(disk.root + "/" + ppath)
+// prepare returns the full path of a file to write appending the disk
+// extension if the path has none and creates its parent directories.
+func (disk *Disk) prepare(ppath string) (string, error) {
+	ppath = path.Clean("/" + ppath)
 
-	ext := path.Ext(cpath)
+	ext := path.Ext(ppath)
 	if ext == "" {
-		cpath += "." + disk.ext
+		ppath += "." + disk.ext
+	}
+
+	cpath, err := disk.Resolve(ppath)
+	if err != nil {
+		return cpath, err
 	}
 
 	dirpath := path.Dir(cpath)
 
-	_, err := os.Stat(dirpath)
+	_, err = os.Stat(dirpath)
 
 	if err != nil && dirpath != disk.root && dirpath != "" && dirpath != "." && dirpath != "/" {
 		if err := os.MkdirAll(dirpath, disk.dmode); err != nil {
-			log.Error(err)
-			return err
+			return cpath, err
 		}
 	}
+	return cpath, nil
+}
 
-	f, err := os.OpenFile(cpath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, disk.fmode)
+// Dump atomically dumps file content to the Disk replacing original
+// content if file already exist, readers never see a partial write.
+//
+// Parameters:
+// - filepath: The relative path to the file to be appended to.
+// - content: The byte slice to append to the file.
+//
+// Returns:
+// - An error if the file contents cannot be dumped.
+func (disk *Disk) Dump(ppath string, content []byte) error {
+	cpath, err := disk.prepare(ppath)
 	if err != nil {
 		log.Error(err)
 		return err
 	}
-	defer f.Close()
 
-	f.Truncate(0)
-	f.Seek(0, 0)
-	if _, err = f.Write(content); err != nil {
+	if err := WriteFile(cpath, content, disk.fmode, disk.fsync); err != nil {
 		log.Error(err)
 		return err
 	}
@@ -367,10 +313,13 @@ func (disk *Disk) Dump(ppath string, content []byte) error {
 // Returns:
 // - An error if the file cannot be appended to.
 func (disk *Disk) Append(ppath string, content []byte) error {
-	cpath := path.Clean(disk.root + "/" + ppath)
-	ext := path.Ext(cpath)
-	if ext == "" {
-		cpath += "." + disk.ext
+	ppath = path.Clean("/" + ppath)
+	if path.Ext(ppath) == "" {
+		ppath += "." + disk.ext
+	}
+	cpath, err := disk.Resolve(ppath)
+	if err != nil {
+		return err
 	}
 	f, err := os.OpenFile(cpath, os.O_APPEND|os.O_WRONLY, disk.fmode)
 	if err != nil {
@@ -384,26 +333,61 @@ func (disk *Disk) Append(ppath string, content []byte) error {
 	return nil
 }
 
-// Create creates and return disk entry.
+// Create creates and return disk entry. Creation is exclusive, of two
+// concurrent creates of the same file only one succeeds.
 //
 // Parameters:
-// - filepath: The relative path to the file to be appended to.
-// - content: The byte slice to append to the file.
+// - filepath: The relative path to the file to be created.
+// - content: The byte slice to write to the file.
 //
 // Returns:
 // - The disk entry of the created file.
-// - An error if the file cannot be created.
+// - An error wrapping fs.ErrExist if the file already exists.
 func (disk *Disk) Create(ppath string, content []byte) (*Entry, error) {
-	cpath := path.Clean(disk.root + "/" + ppath)
-	_, err := disk.Find(ppath)
-
-	if err == nil {
-		return nil, fmt.Errorf("file already exists: %s", cpath)
+	cpath, err := disk.prepare(ppath)
+	if err != nil {
+		return nil, err
 	}
 
-	err = disk.Dump(ppath, content)
-	if err != nil {
+	if err := CreateFile(cpath, content, disk.fmode, disk.fsync); err != nil {
 		return nil, err
 	}
 	return disk.Find(ppath)
 }
+
+// Next increments and returns the counter of key. Counters are kept
+// in the .seq directory of the disk root which is never listed.
+//
+// Parameters:
+// - key: The name of the counter, usually a form name.
+//
+// Returns:
+// - The next value of the counter starting at 1.
+// - An error if the counter cannot be read or written.
+func (disk *Disk) Next(key string) (int64, error) {
+	disk.lock.Lock()
+	defer disk.lock.Unlock()
+
+	dir := path.Join(disk.root, SEQ_DIR)
+	if err := os.MkdirAll(dir, disk.dmode); err != nil {
+		return 0, err
+	}
+	cpath := path.Join(dir, path.Base(path.Clean("/"+key)))
+
+	var value int64
+	raw, err := os.ReadFile(cpath)
+	if err == nil {
+		value, err = strconv.ParseInt(strings.TrimSpace(string(raw)), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid counter %s: %w", key, err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return 0, err
+	}
+
+	value++
+	if err := WriteFile(cpath, []byte(strconv.FormatInt(value, 10)), disk.fmode, disk.fsync); err != nil {
+		return 0, err
+	}
+	return value, nil
+}
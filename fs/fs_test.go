@@ -1,6 +1,7 @@
 package fs
 
 import (
+	"io/fs"
 	"os"
 	"path"
 	"testing"
@@ -87,3 +88,95 @@ func TestFind(t *testing.T) {
 	assert.Equal(t, "md", entry.Ext())
 	assert.True(t, entry.IsFile())
 }
+
+// TestDump tests that Dump replaces files atomically
+func TestDump(t *testing.T) {
+	tmpDir := t.TempDir()
+	disk := Mount(tmpDir, "index", "md").Fsync(true)
+
+	assert.NoError(t, disk.Dump("posts/first", []byte("old")))
+	assert.NoError(t, disk.Dump("posts/first", []byte("new")))
+
+	content, err := disk.Read("posts/first.md")
+	assert.NoError(t, err)
+	assert.Equal(t, "new", string(content))
+
+	info, err := os.Stat(path.Join(tmpDir, "posts/first.md"))
+	assert.NoError(t, err)
+	assert.Equal(t, disk.Fmode(), info.Mode().Perm())
+
+	// Failed writes leave no temporary files behind
+	assert.NoError(t, os.Mkdir(path.Join(tmpDir, "posts/dir.md"), 0755))
+	assert.Error(t, disk.Dump("posts/dir", []byte("content")))
+
+	files, err := os.ReadDir(path.Join(tmpDir, "posts"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+}
+
+// TestListSkipsTemp tests that List skips files of writes in progress
+func TestListSkipsTemp(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(path.Join(tmpDir, "file1.md"), []byte("content"), 0644)
+	os.WriteFile(path.Join(tmpDir, ".file2.md.1234.tmp"), []byte("cont"), 0644)
+
+	disk := Mount(tmpDir, "index", "")
+	entries, err := disk.List("")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "file1", entries[0].Name())
+}
+
+// TestCreate tests that concurrent creates of a file only succeed once
+func TestCreate(t *testing.T) {
+	tmpDir := t.TempDir()
+	disk := Mount(tmpDir, "index", "md")
+
+	created := make(chan error, 16)
+	for i := 0; i < cap(created); i++ {
+		go func() {
+			_, err := disk.Create("posts/same", []byte("content"))
+			created <- err
+		}()
+	}
+	success := 0
+	for i := 0; i < cap(created); i++ {
+		err := <-created
+		if err == nil {
+			success++
+		} else {
+			assert.ErrorIs(t, err, fs.ErrExist)
+		}
+	}
+	assert.Equal(t, 1, success)
+
+	entry, err := disk.Find("posts/same")
+	assert.NoError(t, err)
+	content, err := entry.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, "content", string(content))
+
+	files, err := os.ReadDir(path.Join(tmpDir, "posts"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+// TestNext tests the Disk counters
+func TestNext(t *testing.T) {
+	tmpDir := t.TempDir()
+	disk := Mount(tmpDir, "index", "")
+
+	for expected := int64(1); expected <= 3; expected++ {
+		value, err := disk.Next("contact")
+		assert.NoError(t, err)
+		assert.Equal(t, expected, value)
+	}
+
+	// Counters survive remounts and are not listed
+	value, err := Mount(tmpDir, "index", "").Next("contact")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), value)
+	entries, err := disk.List("")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 0)
+}
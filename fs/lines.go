@@ -0,0 +1,579 @@
+package fs
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"julien/driver"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Line is a single JSON line record of a Lines storage.
+type Line struct {
+	Name    string                 `json:"name"`              // Entry name without extension.
+	Time    time.Time              `json:"time"`              // Time the record was appended.
+	Size    int                    `json:"size"`              // Size of the original document.
+	Format  string                 `json:"format,omitempty"`  // Driver the document was written with.
+	Data    map[string]interface{} `json:"data,omitempty"`    // Document frontmatter.
+	Body    string                 `json:"body,omitempty"`    // Document body.
+	Deleted bool                   `json:"deleted,omitempty"` // Tombstone of a removed entry.
+
+	file   string // Line file of an indexed record.
+	offset int64  // Offset of the line in file.
+	length int    // Length of the line in bytes.
+}
+
+// header is the part of a line kept in the index, the data and
+// body of a record are read from its line file when needed.
+type header struct {
+	Name    string    `json:"name"`
+	Time    time.Time `json:"time"`
+	Size    int       `json:"size"`
+	Deleted bool      `json:"deleted,omitempty"`
+}
+
+// lineset indexes the latest record of every name of a directory.
+type lineset struct {
+	records map[string]*Line // Latest record by name without data and body.
+	offsets map[string]int64 // Bytes read per file.
+}
+
+// Lines represents an append-only storage keeping every directory, usually
+// a form, as JSON lines in <root>/<dir>.jsonl or, when rotated daily, in
+// <root>/<dir>/<date>.jsonl. Later lines replace earlier lines with the
+// same name. Only the position of the latest record of each name is kept
+// in memory, records are read from their line file when needed.
+type Lines struct {
+	root   string              // The root directory of the storage.
+	ext    string              // Default file extension to use.
+	index  string              // Default index file name.
+	rotate string              // Rotation of the line files, "" or "daily".
+	fmode  fs.FileMode         // File mode permissions for files.
+	dmode  fs.FileMode         // File mode permissions for directories.
+	sets   map[string]*lineset // Index of the records read so far by directory.
+	lock   sync.Mutex          // Guards sets and appends.
+}
+
+// MountLines creates a new Lines storage at the given root directory.
+//
+// Parameters:
+// - root: The directory holding the line files.
+// - index: The default index file name.
+// - ext: The default file extension of the entries.
+// - rotate: "daily" to start a new line file every day, "" for one file per directory.
+//
+// Returns:
+// - A pointer to the newly created Lines storage.
+func MountLines(root string, index string, ext string, rotate string) *Lines {
+	return &Lines{
+		root:   root,
+		ext:    ext,
+		index:  index,
+		rotate: rotate,
+		fmode:  0644,
+		dmode:  0755,
+		sets:   make(map[string]*lineset),
+	}
+}
+
+func (disk *Lines) Root() string {
+	return disk.root
+}
+
+func (disk *Lines) Ext() string {
+	return disk.ext
+}
+
+func (disk *Lines) Index() string {
+	return disk.index
+}
+
+func (disk *Lines) IName() string {
+	return disk.index + "." + disk.ext
+}
+
+// split splits a storage path into its directory and entry name
+// without the disk extension.
+func (disk *Lines) split(ppath string) (string, string) {
+	ppath = strings.Trim(path.Clean("/"+ppath), "/")
+	dir, name, _ := strings.Cut(ppath, "/")
+	if HasExt(name, disk.ext) {
+		name = strings.TrimSuffix(name, "."+disk.ext)
+	}
+	return dir, name
+}
+
+// files returns the line files of a directory oldest first.
+func (disk *Lines) files(dir string) []string {
+	files := make([]string, 0)
+	single := path.Join(disk.root, dir+".jsonl")
+	if _, err := os.Stat(single); err == nil {
+		files = append(files, single)
+	}
+	entries, err := os.ReadDir(path.Join(disk.root, dir))
+	if err != nil {
+		return files
+	}
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && HasExt(entry.Name(), "jsonl") {
+			files = append(files, path.Join(disk.root, dir, entry.Name()))
+		}
+	}
+	return files
+}
+
+// load reads the lines appended to the files of a directory since the
+// last load, disk.lock must be held.
+func (disk *Lines) load(dir string) (*lineset, error) {
+	set, ok := disk.sets[dir]
+	if !ok {
+		set = &lineset{
+			records: make(map[string]*Line),
+			offsets: make(map[string]int64),
+		}
+		disk.sets[dir] = set
+	}
+
+	for _, file := range disk.files(dir) {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		offset := set.offsets[file]
+		if info.Size() == offset {
+			continue
+		}
+		if info.Size() < offset {
+			// File was truncated or replaced outside
+			// julien so start over from scratch
+			delete(disk.sets, dir)
+			return disk.load(dir)
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			f.Close()
+			return nil, err
+		}
+		reader := bufio.NewReader(f)
+		for {
+			raw, err := reader.ReadBytes('\n')
+			if err != nil {
+				// Leave incomplete lines for the next load
+				break
+			}
+			start := offset
+			offset += int64(len(raw))
+			head := header{}
+			if err := json.Unmarshal(raw, &head); err != nil || head.Name == "" {
+				continue
+			}
+			set.records[head.Name] = &Line{
+				Name:    head.Name,
+				Time:    head.Time,
+				Size:    head.Size,
+				Deleted: head.Deleted,
+				file:    file,
+				offset:  start,
+				length:  len(raw),
+			}
+		}
+		f.Close()
+		set.offsets[file] = offset
+	}
+	return set, nil
+}
+
+// find returns the latest record of an entry, disk.lock must be held.
+func (disk *Lines) find(dir string, name string) (*Line, error) {
+	set, err := disk.load(dir)
+	if err != nil {
+		return nil, err
+	}
+	record, ok := set.records[name]
+	if !ok || record.Deleted {
+		return nil, &fs.PathError{Op: "find", Path: path.Join(dir, name), Err: fs.ErrNotExist}
+	}
+	return record, nil
+}
+
+// read reads the data and body of an indexed record from its line file.
+func (disk *Lines) read(index *Line) (*Line, error) {
+	f, err := os.Open(index.file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	raw := make([]byte, index.length)
+	if _, err := f.ReadAt(raw, index.offset); err != nil {
+		return nil, err
+	}
+	record := &Line{}
+	if err := json.Unmarshal(raw, record); err != nil {
+		return nil, fmt.Errorf("%s: %w", index.file, err)
+	}
+	return record, nil
+}
+
+// write appends a record to the current line file of a directory,
+// disk.lock must be held.
+func (disk *Lines) write(dir string, record *Line) error {
+	file := path.Join(disk.root, dir+".jsonl")
+	if disk.rotate == "daily" {
+		file = path.Join(disk.root, dir, record.Time.Format("2006-01-02")+".jsonl")
+	}
+	if err := os.MkdirAll(path.Dir(file), disk.dmode); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, disk.fmode)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Terminate a line torn by a crash so it
+	// does not swallow the record written now
+	if info, err := f.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if rf, err := os.Open(file); err == nil {
+			if _, err := rf.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+				raw = append([]byte{'\n'}, raw...)
+			}
+			rf.Close()
+		}
+	}
+
+	if _, err := f.Write(append(raw, '\n')); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	_, err = disk.load(dir)
+	return err
+}
+
+// record converts document content into a line record.
+func (disk *Lines) record(name string, content []byte) (*Line, error) {
+	record := &Line{
+		Name: name,
+		Time: time.Now(),
+		Size: len(content),
+	}
+	format, ddriver, ok := driver.Detect(content)
+	if !ok {
+		// Not a frontmatter document, keep it as is
+		record.Body = string(content)
+		return record, nil
+	}
+	frontmatter, body, err := ddriver.Parse(content)
+	if err != nil {
+		return nil, err
+	}
+	data, _ := driver.Denormalize(*frontmatter).(map[string]interface{})
+	record.Format = format
+	record.Data = data
+	record.Body = body
+	return record, nil
+}
+
+// content converts a line record back into document content.
+func (disk *Lines) content(record *Line) ([]byte, error) {
+	if record.Format == "" {
+		return []byte(record.Body), nil
+	}
+	ddriver, err := driver.Find(record.Format)
+	if err != nil {
+		return nil, err
+	}
+	data := record.Data
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	return ddriver.Dump(&data, record.Body)
+}
+
+func (disk *Lines) entry(dir string, record *Line) *Entry {
+	info := NewFileInfo(record.Name+"."+disk.ext, int64(record.Size), record.Time, false)
+	dentry := NewEntry(disk, info, path.Join(dir, record.Name+"."+disk.ext))
+	return &dentry
+}
+
+// List lists the entries of a directory sorted by name.
+//
+// Parameters:
+// - rpath: The directory path, an empty path lists the indexed directories.
+//
+// Returns:
+// - A slice of pointers to Entry objects.
+// - An error if the directory cannot be read.
+func (disk *Lines) List(rpath string) ([]*Entry, error) {
+	disk.lock.Lock()
+	defer disk.lock.Unlock()
+	dentries := make([]*Entry, 0)
+	dir, name := disk.split(rpath)
+
+	if dir == "" {
+		entries, err := os.ReadDir(disk.root)
+		if err != nil {
+			return dentries, err
+		}
+		dirs := make(map[string]bool)
+		for _, entry := range entries {
+			dname, ext := GetNameParts(entry.Name())
+			if entry.IsDir() {
+				dname = entry.Name()
+			} else if ext != "jsonl" {
+				continue
+			}
+			if dirs[dname] {
+				continue
+			}
+			dirs[dname] = true
+			record, err := disk.find(dname, disk.index)
+			if err != nil {
+				continue
+			}
+			dentry := NewEntry(disk, NewFileInfo(dname, 0, record.Time, true), dname)
+			dentries = append(dentries, &dentry)
+		}
+		return dentries, nil
+	}
+
+	if name != "" || len(disk.files(dir)) == 0 {
+		return dentries, &fs.PathError{Op: "list", Path: rpath, Err: fs.ErrNotExist}
+	}
+
+	set, err := disk.load(dir)
+	if err != nil {
+		return dentries, err
+	}
+	names := make([]string, 0, len(set.records))
+	for rname, record := range set.records {
+		if !record.Deleted {
+			names = append(names, rname)
+		}
+	}
+	sort.Strings(names)
+	for _, rname := range names {
+		dentries = append(dentries, disk.entry(dir, set.records[rname]))
+	}
+	return dentries, nil
+}
+
+// Find locates the latest record of an entry or an indexed directory.
+//
+// Parameters:
+// - filepath: The path to the entry.
+//
+// Returns:
+// - A pointer to the Entry if found.
+// - An error if the entry cannot be found.
+func (disk *Lines) Find(filepath string) (*Entry, error) {
+	disk.lock.Lock()
+	defer disk.lock.Unlock()
+	dir, name := disk.split(filepath)
+
+	if dir == "" || name == "" {
+		record, err := disk.find(dir, disk.index)
+		if err != nil {
+			return nil, err
+		}
+		dentry := NewEntry(disk, NewFileInfo(dir, 0, record.Time, true), dir)
+		return &dentry, nil
+	}
+
+	record, err := disk.find(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	return disk.entry(dir, record), nil
+}
+
+// Read reads the latest content of an entry.
+//
+// Parameters:
+// - filepath: The path to the entry.
+//
+// Returns:
+// - The content of the entry as a byte slice.
+// - An error if the entry cannot be read.
+func (disk *Lines) Read(filepath string) ([]byte, error) {
+	disk.lock.Lock()
+	defer disk.lock.Unlock()
+	index, err := disk.find(disk.split(filepath))
+	if err != nil {
+		return nil, err
+	}
+	record, err := disk.read(index)
+	if err != nil {
+		return nil, err
+	}
+	return disk.content(record)
+}
+
+// Dump appends a record replacing the content of an entry.
+//
+// Parameters:
+// - ppath: The path to the entry.
+// - content: The document content to store.
+//
+// Returns:
+// - An error if the content cannot be dumped.
+func (disk *Lines) Dump(ppath string, content []byte) error {
+	disk.lock.Lock()
+	defer disk.lock.Unlock()
+	dir, name := disk.split(ppath)
+	if dir == "" || name == "" {
+		return fmt.Errorf("invalid jsonl path: %s", ppath)
+	}
+	record, err := disk.record(name, content)
+	if err != nil {
+		return err
+	}
+	return disk.write(dir, record)
+}
+
+// Append appends content to the body of an entry.
+//
+// Parameters:
+// - ppath: The path to the entry.
+// - content: The content to append.
+//
+// Returns:
+// - An error if the entry does not exist or cannot be appended to.
+func (disk *Lines) Append(ppath string, content []byte) error {
+	disk.lock.Lock()
+	defer disk.lock.Unlock()
+	dir, name := disk.split(ppath)
+	index, err := disk.find(dir, name)
+	if err != nil {
+		return err
+	}
+	current, err := disk.read(index)
+	if err != nil {
+		return err
+	}
+	raw, err := disk.content(current)
+	if err != nil {
+		return err
+	}
+	record, err := disk.record(name, append(raw, content...))
+	if err != nil {
+		return err
+	}
+	return disk.write(dir, record)
+}
+
+// Create appends the first record of an entry.
+//
+// Parameters:
+// - ppath: The path to the entry.
+// - content: The document content to store.
+//
+// Returns:
+// - The entry of the created record.
+// - An error if the entry already exists or cannot be created.
+func (disk *Lines) Create(ppath string, content []byte) (*Entry, error) {
+	disk.lock.Lock()
+	defer disk.lock.Unlock()
+	dir, name := disk.split(ppath)
+	if dir == "" || name == "" {
+		return nil, fmt.Errorf("invalid jsonl path: %s", ppath)
+	}
+	if _, err := disk.find(dir, name); err == nil {
+		return nil, &fs.PathError{Op: "create", Path: path.Join(dir, name), Err: fs.ErrExist}
+	}
+	record, err := disk.record(name, content)
+	if err != nil {
+		return nil, err
+	}
+	if err := disk.write(dir, record); err != nil {
+		return nil, err
+	}
+	return disk.entry(dir, record), nil
+}
+
+// Next increments and returns the counter of key. Counters are kept
+// in the .seq directory of the root which is never listed.
+//
+// Parameters:
+// - key: The name of the counter, usually a form name.
+//
+// Returns:
+// - The next value of the counter starting at 1.
+// - An error if the counter cannot be read or written.
+func (disk *Lines) Next(key string) (int64, error) {
+	disk.lock.Lock()
+	defer disk.lock.Unlock()
+
+	dir := path.Join(disk.root, SEQ_DIR)
+	if err := os.MkdirAll(dir, disk.dmode); err != nil {
+		return 0, err
+	}
+	cpath := path.Join(dir, path.Base(path.Clean("/"+key)))
+
+	var value int64
+	raw, err := os.ReadFile(cpath)
+	if err == nil {
+		value, err = strconv.ParseInt(strings.TrimSpace(string(raw)), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid counter %s: %w", key, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return 0, err
+	}
+
+	value++
+	if err := WriteFile(cpath, []byte(strconv.FormatInt(value, 10)), disk.fmode, true); err != nil {
+		return 0, err
+	}
+	return value, nil
+}
+
+// Remove appends a tombstone for an entry, or deletes every line file
+// of a directory.
+//
+// Parameters:
+// - filepath: The path to the entry or directory.
+//
+// Returns:
+// - An error if the entry or directory cannot be removed.
+func (disk *Lines) Remove(filepath string) error {
+	disk.lock.Lock()
+	defer disk.lock.Unlock()
+	dir, name := disk.split(filepath)
+	if dir == "" {
+		return fmt.Errorf("invalid jsonl path: %s", filepath)
+	}
+	if name == "" {
+		delete(disk.sets, dir)
+		if err := os.RemoveAll(path.Join(disk.root, dir)); err != nil {
+			return err
+		}
+		err := os.Remove(path.Join(disk.root, dir+".jsonl"))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if _, err := disk.find(dir, name); err != nil {
+		return nil
+	}
+	return disk.write(dir, &Line{Name: name, Time: time.Now(), Deleted: true})
+}
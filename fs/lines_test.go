@@ -0,0 +1,141 @@
+package fs
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLines(t *testing.T) {
+	tmpDir := t.TempDir()
+	disk := MountLines(tmpDir, "index", "md", "")
+
+	assert.NoError(t, disk.Dump("sign-up/one", []byte("---\nemail: one@julien.dev\n---\nhello")))
+	assert.NoError(t, disk.Dump("sign-up/two", []byte("---\nemail: two@julien.dev\n---\n")))
+
+	// One line per submission in a single file per form
+	raw, err := os.ReadFile(path.Join(tmpDir, "sign-up.jsonl"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(raw), "\n"))
+	assert.Contains(t, string(raw), `"data":{"email":"one@julien.dev"}`)
+
+	entry, err := disk.Find("sign-up/one")
+	assert.NoError(t, err)
+	assert.Equal(t, "one", entry.Name())
+	assert.Equal(t, "sign-up/one.md", entry.Path())
+
+	content, err := entry.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, "---\nemail: one@julien.dev\n---\nhello", string(content))
+
+	// Later lines replace earlier lines
+	assert.NoError(t, disk.Dump("sign-up/one.md", []byte("---\nemail: new@julien.dev\n---\n")))
+	content, err = disk.Read("sign-up/one.md")
+	assert.NoError(t, err)
+	assert.Equal(t, "---\nemail: new@julien.dev\n---\n", string(content))
+
+	entries, err := disk.List("sign-up")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "one", entries[0].Name())
+
+	_, err = disk.Create("sign-up/two", []byte("---\nemail: again@julien.dev\n---\n"))
+	assert.Error(t, err)
+
+	assert.NoError(t, disk.Remove("sign-up/two"))
+	entries, err = disk.List("sign-up")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// A fresh mount reads the same records back
+	reopened := MountLines(tmpDir, "index", "md", "")
+	entries, err = reopened.List("sign-up")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestLinesRotate(t *testing.T) {
+	tmpDir := t.TempDir()
+	disk := MountLines(tmpDir, "index", "md", "daily")
+
+	assert.NoError(t, disk.Dump("sign-up/one", []byte("---\nemail: one@julien.dev\n---\n")))
+
+	files, err := os.ReadDir(path.Join(tmpDir, "sign-up"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+	assert.True(t, HasExt(files[0].Name(), "jsonl"))
+
+	_, err = disk.Find("sign-up/one")
+	assert.NoError(t, err)
+}
+
+func TestLinesTorn(t *testing.T) {
+	tmpDir := t.TempDir()
+	disk := MountLines(tmpDir, "index", "md", "")
+	assert.NoError(t, disk.Dump("sign-up/one", []byte("---\nemail: one@julien.dev\n---\n")))
+
+	// A crash left half a line behind
+	file := path.Join(tmpDir, "sign-up.jsonl")
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND, 0644)
+	assert.NoError(t, err)
+	f.WriteString(`{"name":"torn","da`)
+	f.Close()
+
+	_, err = disk.Find("sign-up/torn")
+	assert.Error(t, err)
+
+	assert.NoError(t, disk.Dump("sign-up/two", []byte("---\nemail: two@julien.dev\n---\n")))
+	entries, err := disk.List("sign-up")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	// A fresh mount reads the same records
+	entries, err = MountLines(tmpDir, "index", "md", "").List("sign-up")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestLinesNext(t *testing.T) {
+	tmpDir := t.TempDir()
+	disk := MountLines(tmpDir, "index", "md", "")
+	assert.NoError(t, disk.Dump("sign-up/index", []byte("---\ntitle: Sign up\n---\n")))
+
+	for expected := int64(1); expected <= 3; expected++ {
+		value, err := disk.Next("sign-up")
+		assert.NoError(t, err)
+		assert.Equal(t, expected, value)
+	}
+
+	// Counters survive removals and remounts and are not listed
+	assert.NoError(t, disk.Remove("sign-up/index"))
+	value, err := MountLines(tmpDir, "index", "md", "").Next("sign-up")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), value)
+	entries, err := disk.List("")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 0)
+}
+
+func TestLinesIndex(t *testing.T) {
+	tmpDir := t.TempDir()
+	disk := MountLines(tmpDir, "index", "md", "daily")
+	assert.NoError(t, disk.Dump("sign-up/one", []byte("---\nemail: one@julien.dev\n---\nhello")))
+	assert.NoError(t, disk.Dump("sign-up/two", []byte("---\nemail: two@julien.dev\n---\n")))
+	assert.NoError(t, disk.Append("sign-up/one", []byte(" again")))
+
+	// Only the position of records is kept in memory
+	reopened := MountLines(tmpDir, "index", "md", "daily")
+	entries, err := reopened.List("sign-up")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	for _, record := range reopened.sets["sign-up"].records {
+		assert.Nil(t, record.Data)
+		assert.Empty(t, record.Body)
+	}
+	content, err := reopened.Read("sign-up/one")
+	assert.NoError(t, err)
+	assert.Equal(t, "---\nemail: one@julien.dev\n---\nhello again", string(content))
+}
@@ -0,0 +1,314 @@
+package fs
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memfile is a single file of a Memory storage.
+type memfile struct {
+	content []byte    // File content.
+	modtime time.Time // Modification time of the file.
+}
+
+// Memory represents a storage kept in memory, handy for tests and
+// previews that should not touch the file system. Directories exist
+// implicitly for every file stored below them.
+type Memory struct {
+	files map[string]*memfile // Files by cleaned path.
+	index string              // Default index file name.
+	ext   string              // Default file extension to use.
+	seqs  map[string]int64    // Counters by key.
+	lock  sync.RWMutex        // Guards files and counters.
+}
+
+// NewMemory creates an empty Memory storage.
+//
+// Parameters:
+// - index: The default index file name.
+// - ext: The default file extension to use.
+//
+// Returns:
+// - A pointer to the newly created Memory storage.
+func NewMemory(index string, ext string) *Memory {
+	return &Memory{
+		files: make(map[string]*memfile),
+		seqs:  make(map[string]int64),
+		index: index,
+		ext:   ext,
+	}
+}
+
+func (disk *Memory) Root() string {
+	return "memory://"
+}
+
+func (disk *Memory) Ext() string {
+	return disk.ext
+}
+
+func (disk *Memory) Index() string {
+	return disk.index
+}
+
+func (disk *Memory) IName() string {
+	return disk.index + "." + disk.ext
+}
+
+// FS returns the storage as a read only fs.FS.
+func (disk *Memory) FS() fs.FS {
+	return &memfs{disk: disk}
+}
+
+// List lists all entries in the specified directory.
+//
+// Parameters:
+// - rpath: The relative path of the directory to list.
+//
+// Returns:
+// - A slice of pointers to Entry objects.
+// - An error if the directory does not exist.
+func (disk *Memory) List(rpath string) ([]*Entry, error) {
+	return list(disk, disk.FS(), rpath)
+}
+
+// Find locates a file or directory within the storage.
+//
+// Parameters:
+// - filepath: The relative path to the file or directory.
+//
+// Returns:
+// - A pointer to the Entry if found.
+// - An error if the file or directory cannot be found.
+func (disk *Memory) Find(filepath string) (*Entry, error) {
+	return find(disk, disk.FS(), filepath)
+}
+
+// Read reads the content of a file.
+//
+// Parameters:
+// - filepath: The relative path to the file to be read.
+//
+// Returns:
+// - A copy of the content of the file.
+// - An error if the file does not exist.
+func (disk *Memory) Read(filepath string) ([]byte, error) {
+	disk.lock.RLock()
+	defer disk.lock.RUnlock()
+	file, ok := disk.files[clean(filepath)]
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: filepath, Err: fs.ErrNotExist}
+	}
+	return bytes.Clone(file.content), nil
+}
+
+// name returns the cleaned path of a file appending the disk
+// extension if the path has none.
+func (disk *Memory) name(ppath string) string {
+	cpath := clean(ppath)
+	if path.Ext(cpath) == "" {
+		cpath += "." + disk.ext
+	}
+	return cpath
+}
+
+// Dump dumps file content replacing original content.
+//
+// Parameters:
+// - ppath: The relative path to the file.
+// - content: The byte slice to store.
+//
+// Returns:
+// - An error if the path is invalid.
+func (disk *Memory) Dump(ppath string, content []byte) error {
+	cpath := disk.name(ppath)
+	if cpath == "." || !fs.ValidPath(cpath) {
+		return &fs.PathError{Op: "dump", Path: ppath, Err: fs.ErrInvalid}
+	}
+	disk.lock.Lock()
+	defer disk.lock.Unlock()
+	disk.files[cpath] = &memfile{
+		content: bytes.Clone(content),
+		modtime: time.Now(),
+	}
+	return nil
+}
+
+// Append appends content to an existing file.
+//
+// Parameters:
+// - ppath: The relative path to the file.
+// - content: The byte slice to append.
+//
+// Returns:
+// - An error if the file does not exist.
+func (disk *Memory) Append(ppath string, content []byte) error {
+	cpath := disk.name(ppath)
+	disk.lock.Lock()
+	defer disk.lock.Unlock()
+	file, ok := disk.files[cpath]
+	if !ok {
+		return &fs.PathError{Op: "append", Path: ppath, Err: fs.ErrNotExist}
+	}
+	file.content = append(file.content, content...)
+	file.modtime = time.Now()
+	return nil
+}
+
+// Create creates and return a storage entry.
+//
+// Parameters:
+// - ppath: The relative path to the file.
+// - content: The byte slice to store.
+//
+// Returns:
+// - The entry of the created file.
+// - An error wrapping fs.ErrExist if the file already exists.
+func (disk *Memory) Create(ppath string, content []byte) (*Entry, error) {
+	cpath := disk.name(ppath)
+	if cpath == "." || !fs.ValidPath(cpath) {
+		return nil, &fs.PathError{Op: "create", Path: ppath, Err: fs.ErrInvalid}
+	}
+	disk.lock.Lock()
+	if _, ok := disk.files[cpath]; ok {
+		disk.lock.Unlock()
+		return nil, &fs.PathError{Op: "create", Path: cpath, Err: fs.ErrExist}
+	}
+	disk.files[cpath] = &memfile{
+		content: bytes.Clone(content),
+		modtime: time.Now(),
+	}
+	disk.lock.Unlock()
+	return disk.Find(cpath)
+}
+
+// Next increments and returns the counter of key.
+//
+// Parameters:
+// - key: The name of the counter.
+//
+// Returns:
+// - The next value of the counter starting at 1.
+// - Always nil.
+func (disk *Memory) Next(key string) (int64, error) {
+	disk.lock.Lock()
+	defer disk.lock.Unlock()
+	disk.seqs[key]++
+	return disk.seqs[key], nil
+}
+
+// Remove deletes a file or directory.
+//
+// Parameters:
+// - filepath: The relative path to the file or directory.
+//
+// Returns:
+// - Always nil, removing missing files is not an error.
+func (disk *Memory) Remove(filepath string) error {
+	cpath := clean(filepath)
+	disk.lock.Lock()
+	defer disk.lock.Unlock()
+	for name := range disk.files {
+		if cpath == "." || name == cpath || strings.HasPrefix(name, cpath+"/") {
+			delete(disk.files, name)
+		}
+	}
+	return nil
+}
+
+// memfs is the fs.FS view of a Memory storage.
+type memfs struct {
+	disk *Memory
+}
+
+func (mfs *memfs) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	disk := mfs.disk
+	disk.lock.RLock()
+	defer disk.lock.RUnlock()
+
+	if file, ok := disk.files[name]; ok {
+		info := NewFileInfo(name, int64(len(file.content)), file.modtime, false)
+		return &memopen{info: info, reader: bytes.NewReader(bytes.Clone(file.content))}, nil
+	}
+
+	prefix := name + "/"
+	if name == "." {
+		prefix = ""
+	}
+	children := make(map[string]fs.FileInfo)
+	modtime := time.Time{}
+	for fname, file := range disk.files {
+		if !strings.HasPrefix(fname, prefix) {
+			continue
+		}
+		child, rest, isdir := strings.Cut(fname[len(prefix):], "/")
+		if file.modtime.After(modtime) {
+			modtime = file.modtime
+		}
+		if isdir {
+			children[child] = NewFileInfo(child, 0, file.modtime, true)
+		} else if rest == "" {
+			children[child] = NewFileInfo(child, int64(len(file.content)), file.modtime, false)
+		}
+	}
+	if len(children) == 0 && name != "." {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(children))
+	for _, info := range children {
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return &memopen{info: NewFileInfo(name, 0, modtime, true), entries: entries}, nil
+}
+
+// memopen is an open file or directory of a memfs.
+type memopen struct {
+	info    fs.FileInfo
+	reader  *bytes.Reader
+	entries []fs.DirEntry
+}
+
+func (file *memopen) Stat() (fs.FileInfo, error) {
+	return file.info, nil
+}
+
+func (file *memopen) Read(buffer []byte) (int, error) {
+	if file.reader == nil {
+		return 0, &fs.PathError{Op: "read", Path: file.info.Name(), Err: fs.ErrInvalid}
+	}
+	return file.reader.Read(buffer)
+}
+
+func (file *memopen) Close() error {
+	return nil
+}
+
+func (file *memopen) ReadDir(count int) ([]fs.DirEntry, error) {
+	if count <= 0 {
+		entries := file.entries
+		file.entries = nil
+		return entries, nil
+	}
+	if len(file.entries) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(file.entries) {
+		count = len(file.entries)
+	}
+	entries := file.entries[:count]
+	file.entries = file.entries[count:]
+	return entries, nil
+}
@@ -0,0 +1,52 @@
+package fs
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory(t *testing.T) {
+	disk := NewMemory("index", "md")
+
+	assert.NoError(t, disk.Dump("index", []byte("home")))
+	assert.NoError(t, disk.Dump("articles/index", []byte("articles")))
+	assert.NoError(t, disk.Dump("articles/one", []byte("one")))
+	assert.NoError(t, disk.Dump("drafts/two", []byte("two")))
+
+	entry, err := disk.Find("articles/one")
+	assert.NoError(t, err)
+	assert.Equal(t, "one", entry.Name())
+	assert.Equal(t, "articles/one.md", entry.Path())
+	assert.True(t, entry.IsFile())
+
+	content, err := entry.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, "one", string(content))
+
+	// Directories need an index file
+	dir, err := disk.Find("articles")
+	assert.NoError(t, err)
+	assert.True(t, dir.IsDir())
+	_, err = disk.Find("drafts")
+	assert.Error(t, err)
+
+	entries, err := disk.List("")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "articles", entries[0].Name())
+	assert.Equal(t, "index", entries[1].Name())
+
+	_, err = disk.Create("articles/one", []byte("again"))
+	assert.ErrorIs(t, err, fs.ErrExist)
+
+	assert.NoError(t, disk.Append("articles/one", []byte(" more")))
+	content, err = disk.Read("articles/one.md")
+	assert.NoError(t, err)
+	assert.Equal(t, "one more", string(content))
+
+	assert.NoError(t, disk.Remove("articles"))
+	_, err = disk.Find("articles/one")
+	assert.Error(t, err)
+}
@@ -0,0 +1,137 @@
+package fs
+
+import (
+	"io"
+	"io/fs"
+	"sort"
+)
+
+// Overlay is a read only fs.FS stacking file systems on top of each
+// other. A file of an upper layer hides the file at the same path in
+// the layers below it, while directories list the entries of every layer.
+type Overlay struct {
+	layers []fs.FS // Layers from the top most to the bottom most.
+}
+
+// NewOverlay creates an Overlay of the given layers.
+//
+// Parameters:
+// - layers: The file systems to stack, from the top most to the bottom most.
+//
+// Returns:
+// - A pointer to the newly created Overlay.
+func NewOverlay(layers ...fs.FS) *Overlay {
+	return &Overlay{layers: layers}
+}
+
+// Layers returns the layers of the overlay from the top most.
+func (overlay *Overlay) Layers() []fs.FS {
+	return overlay.layers
+}
+
+// Open opens the named file of the top most layer holding it.
+//
+// Parameters:
+// - name: The slash separated path of the file.
+//
+// Returns:
+// - The opened file, directories list the entries of every layer.
+// - An error if no layer holds the file.
+func (overlay *Overlay) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	for _, layer := range overlay.layers {
+		file, err := layer.Open(name)
+		if err != nil {
+			continue
+		}
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			continue
+		}
+		if !info.IsDir() {
+			return file, nil
+		}
+		file.Close()
+		return &overlaydir{overlay: overlay, name: name, info: info}, nil
+	}
+
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDir lists the merged entries of the named directory of every layer.
+func (overlay *Overlay) ReadDir(name string) ([]fs.DirEntry, error) {
+	seen := make(map[string]bool)
+	entries := make([]fs.DirEntry, 0)
+	found := false
+	for _, layer := range overlay.layers {
+		lentries, err := fs.ReadDir(layer, name)
+		if err != nil {
+			continue
+		}
+		found = true
+		for _, entry := range lentries {
+			if seen[entry.Name()] {
+				continue
+			}
+			seen[entry.Name()] = true
+			entries = append(entries, entry)
+		}
+	}
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+// overlaydir is an open directory of an Overlay.
+type overlaydir struct {
+	overlay *Overlay
+	name    string
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	read    bool
+}
+
+func (dir *overlaydir) Stat() (fs.FileInfo, error) {
+	return dir.info, nil
+}
+
+func (dir *overlaydir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: dir.name, Err: fs.ErrInvalid}
+}
+
+func (dir *overlaydir) Close() error {
+	return nil
+}
+
+func (dir *overlaydir) ReadDir(count int) ([]fs.DirEntry, error) {
+	if !dir.read {
+		entries, err := dir.overlay.ReadDir(dir.name)
+		if err != nil {
+			return nil, err
+		}
+		dir.entries = entries
+		dir.read = true
+	}
+	if count <= 0 {
+		entries := dir.entries
+		dir.entries = nil
+		return entries, nil
+	}
+	if len(dir.entries) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(dir.entries) {
+		count = len(dir.entries)
+	}
+	entries := dir.entries[:count]
+	dir.entries = dir.entries[count:]
+	return entries, nil
+}
@@ -0,0 +1,65 @@
+package fs
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func overlay() *Overlay {
+	site := fstest.MapFS{
+		"views/page.html":    {Data: []byte("site page")},
+		"partials/nav.html":  {Data: []byte("site nav")},
+		"public/site.css":    {Data: []byte("site css")},
+		"layouts/extra.html": {Data: []byte("site layout")},
+	}
+	theme := fstest.MapFS{
+		"index.md":            {Data: []byte("---\ntype: html\n---\n")},
+		"views/page.html":     {Data: []byte("theme page")},
+		"views/post.html":     {Data: []byte("theme post")},
+		"partials/nav.html":   {Data: []byte("theme nav")},
+		"partials/foot.html":  {Data: []byte("theme foot")},
+		"layouts/main.html":   {Data: []byte("theme layout")},
+		"public/theme.css":    {Data: []byte("theme css")},
+		"public/img/logo.png": {Data: []byte("logo")},
+	}
+	return NewOverlay(site, theme)
+}
+
+func TestOverlayFS(t *testing.T) {
+	err := fstest.TestFS(overlay(),
+		"index.md", "views/page.html", "views/post.html", "partials/nav.html",
+		"partials/foot.html", "layouts/main.html", "layouts/extra.html",
+		"public/site.css", "public/theme.css", "public/img/logo.png")
+	assert.NoError(t, err)
+}
+
+func TestOverlayShadows(t *testing.T) {
+	ofs := overlay()
+
+	content, err := fs.ReadFile(ofs, "views/page.html")
+	assert.NoError(t, err)
+	assert.Equal(t, "site page", string(content))
+
+	content, err = fs.ReadFile(ofs, "views/post.html")
+	assert.NoError(t, err)
+	assert.Equal(t, "theme post", string(content))
+
+	_, err = fs.ReadFile(ofs, "views/missing.html")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestOverlayReadDir(t *testing.T) {
+	entries, err := fs.ReadDir(overlay(), "partials")
+	assert.NoError(t, err)
+	names := make([]string, 0)
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.Equal(t, []string{"foot.html", "nav.html"}, names)
+
+	_, err = fs.ReadDir(overlay(), "missing")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
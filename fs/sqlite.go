@@ -0,0 +1,375 @@
+package fs
+
+import (
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLite represents a storage kept in a single sqlite database file with
+// one table per top level directory, usually one table per form.
+type SQLite struct {
+	db    *sql.DB    // The database handle.
+	root  string     // Path to the database file.
+	ext   string     // Default file extension to use.
+	index string     // Default index file name.
+	lock  sync.Mutex // Serializes read-modify-write operations.
+}
+
+// OpenSQLite opens or creates the sqlite database at dbpath.
+//
+// Parameters:
+// - dbpath: The path to the database file.
+// - index: The default index file name.
+// - ext: The default file extension to use.
+//
+// Returns:
+// - A pointer to the newly opened SQLite storage.
+// - An error if the database cannot be opened.
+func OpenSQLite(dbpath string, index string, ext string) (*SQLite, error) {
+	db, err := sql.Open("sqlite3", "file:"+dbpath+"?_journal_mode=WAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLite{
+		db:    db,
+		root:  dbpath,
+		ext:   ext,
+		index: index,
+	}, nil
+}
+
+// Close closes the underlying database.
+func (disk *SQLite) Close() error {
+	return disk.db.Close()
+}
+
+func (disk *SQLite) Root() string {
+	return disk.root
+}
+
+func (disk *SQLite) Ext() string {
+	return disk.ext
+}
+
+func (disk *SQLite) Index() string {
+	return disk.index
+}
+
+func (disk *SQLite) IName() string {
+	return disk.index + "." + disk.ext
+}
+
+// split splits a storage path into its table and row name.
+func (disk *SQLite) split(ppath string) (string, string) {
+	ppath = strings.Trim(path.Clean("/"+ppath), "/")
+	table, name, _ := strings.Cut(ppath, "/")
+	return table, name
+}
+
+// quote quotes a table name for use in a statement.
+func quote(table string) string {
+	return `"` + strings.ReplaceAll(table, `"`, `""`) + `"`
+}
+
+func (disk *SQLite) exists(table string) bool {
+	var name string
+	row := disk.db.QueryRow(`SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?`, table)
+	return row.Scan(&name) == nil
+}
+
+func (disk *SQLite) ensure(table string) error {
+	_, err := disk.db.Exec(`CREATE TABLE IF NOT EXISTS ` + quote(table) + ` (
+		name TEXT PRIMARY KEY,
+		content BLOB NOT NULL,
+		size INTEGER NOT NULL,
+		created INTEGER NOT NULL,
+		modified INTEGER NOT NULL
+	)`)
+	return err
+}
+
+func (disk *SQLite) stat(table string, name string) (fs.FileInfo, error) {
+	var size, modified int64
+	if !disk.exists(table) {
+		return nil, &fs.PathError{Op: "stat", Path: path.Join(table, name), Err: fs.ErrNotExist}
+	}
+	row := disk.db.QueryRow(`SELECT size, modified FROM `+quote(table)+` WHERE name = ?`, name)
+	if err := row.Scan(&size, &modified); err != nil {
+		if err == sql.ErrNoRows {
+			err = fs.ErrNotExist
+		}
+		return nil, &fs.PathError{Op: "stat", Path: path.Join(table, name), Err: err}
+	}
+	return NewFileInfo(name, size, time.Unix(0, modified), false), nil
+}
+
+// List lists the entries stored directly under the given path.
+//
+// Parameters:
+// - rpath: The directory path, an empty path lists the tables.
+//
+// Returns:
+// - A slice of pointers to Entry objects.
+// - An error if the directory cannot be read.
+func (disk *SQLite) List(rpath string) ([]*Entry, error) {
+	dentries := make([]*Entry, 0)
+	table, name := disk.split(rpath)
+
+	if table == "" {
+		rows, err := disk.db.Query(`SELECT name FROM sqlite_master WHERE type = 'table' ORDER BY name`)
+		if err != nil {
+			return dentries, err
+		}
+		tables := make([]string, 0)
+		for rows.Next() {
+			var tname string
+			if err := rows.Scan(&tname); err != nil {
+				rows.Close()
+				return dentries, err
+			}
+			tables = append(tables, tname)
+		}
+		rows.Close()
+		for _, tname := range tables {
+			info, err := disk.stat(tname, disk.IName())
+			if err != nil {
+				continue
+			}
+			dentry := NewEntry(disk, NewFileInfo(tname, 0, info.ModTime(), true), tname)
+			dentries = append(dentries, &dentry)
+		}
+		return dentries, nil
+	}
+
+	if name != "" || !disk.exists(table) {
+		return dentries, &fs.PathError{Op: "list", Path: rpath, Err: fs.ErrNotExist}
+	}
+
+	rows, err := disk.db.Query(`SELECT name, size, modified FROM ` + quote(table) + ` ORDER BY name`)
+	if err != nil {
+		return dentries, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rname string
+		var size, modified int64
+		if err := rows.Scan(&rname, &size, &modified); err != nil {
+			return dentries, err
+		}
+		if strings.Contains(rname, "/") || !HasExt(rname, disk.ext) {
+			continue
+		}
+		info := NewFileInfo(rname, size, time.Unix(0, modified), false)
+		dentry := NewEntry(disk, info, path.Join(table, rname))
+		dentries = append(dentries, &dentry)
+	}
+	return dentries, rows.Err()
+}
+
+// Find locates a row or table within the database.
+//
+// Parameters:
+// - filepath: The path to the row, the disk extension is appended if missing.
+//
+// Returns:
+// - A pointer to the Entry if found.
+// - An error if the entry cannot be found.
+func (disk *SQLite) Find(filepath string) (*Entry, error) {
+	table, name := disk.split(filepath)
+
+	if table == "" || name == "" {
+		// Tables are directories and only
+		// found if they have an index row
+		info, err := disk.stat(table, disk.IName())
+		if err != nil {
+			return nil, err
+		}
+		dpath := table
+		if dpath == "" {
+			dpath = "."
+		}
+		dentry := NewEntry(disk, NewFileInfo(dpath, 0, info.ModTime(), true), dpath)
+		return &dentry, nil
+	}
+
+	info, err := disk.stat(table, name)
+	if err != nil {
+		if path.Ext(name) != "" {
+			return nil, err
+		}
+		name = name + "." + disk.ext
+		info, err = disk.stat(table, name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	dentry := NewEntry(disk, info, path.Join(table, name))
+	return &dentry, nil
+}
+
+// Read reads the content of a row.
+//
+// Parameters:
+// - filepath: The path to the row.
+//
+// Returns:
+// - The content of the row as a byte slice.
+// - An error if the row cannot be read.
+func (disk *SQLite) Read(filepath string) ([]byte, error) {
+	table, name := disk.split(filepath)
+	if !disk.exists(table) {
+		return nil, &fs.PathError{Op: "read", Path: filepath, Err: fs.ErrNotExist}
+	}
+	var content []byte
+	row := disk.db.QueryRow(`SELECT content FROM `+quote(table)+` WHERE name = ?`, name)
+	if err := row.Scan(&content); err != nil {
+		if err == sql.ErrNoRows {
+			err = fs.ErrNotExist
+		}
+		return nil, &fs.PathError{Op: "read", Path: filepath, Err: err}
+	}
+	return content, nil
+}
+
+// Dump dumps content to a row replacing the original content.
+//
+// Parameters:
+// - ppath: The path to the row, the disk extension is appended if missing.
+// - content: The byte slice to store.
+//
+// Returns:
+// - An error if the content cannot be dumped.
+func (disk *SQLite) Dump(ppath string, content []byte) error {
+	table, name := disk.split(ppath)
+	if table == "" || name == "" {
+		return fmt.Errorf("invalid sqlite path: %s", ppath)
+	}
+	if path.Ext(name) == "" {
+		name += "." + disk.ext
+	}
+	if err := disk.ensure(table); err != nil {
+		return err
+	}
+	now := time.Now().UnixNano()
+	_, err := disk.db.Exec(`INSERT INTO `+quote(table)+` (name, content, size, created, modified)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			content = excluded.content,
+			size = excluded.size,
+			modified = excluded.modified`,
+		name, content, len(content), now, now)
+	return err
+}
+
+// Append appends content to a row.
+//
+// Parameters:
+// - ppath: The path to the row, the disk extension is appended if missing.
+// - content: The byte slice to append.
+//
+// Returns:
+// - An error if the row does not exist or cannot be appended to.
+func (disk *SQLite) Append(ppath string, content []byte) error {
+	disk.lock.Lock()
+	defer disk.lock.Unlock()
+	table, name := disk.split(ppath)
+	if path.Ext(name) == "" {
+		name += "." + disk.ext
+	}
+	current, err := disk.Read(path.Join(table, name))
+	if err != nil {
+		return err
+	}
+	return disk.Dump(path.Join(table, name), append(current, content...))
+}
+
+// Create creates a row and returns its entry.
+//
+// Parameters:
+// - ppath: The path to the row, the disk extension is appended if missing.
+// - content: The byte slice to store.
+//
+// Returns:
+// - The entry of the created row.
+// - An error wrapping fs.ErrExist if the row already exists.
+func (disk *SQLite) Create(ppath string, content []byte) (*Entry, error) {
+	table, name := disk.split(ppath)
+	if table == "" || name == "" {
+		return nil, fmt.Errorf("invalid sqlite path: %s", ppath)
+	}
+	if path.Ext(name) == "" {
+		name += "." + disk.ext
+	}
+	if err := disk.ensure(table); err != nil {
+		return nil, err
+	}
+	now := time.Now().UnixNano()
+	result, err := disk.db.Exec(`INSERT INTO `+quote(table)+` (name, content, size, created, modified)
+		VALUES (?, ?, ?, ?, ?) ON CONFLICT(name) DO NOTHING`,
+		name, content, len(content), now, now)
+	if err != nil {
+		return nil, err
+	}
+	if created, err := result.RowsAffected(); err != nil || created == 0 {
+		return nil, &fs.PathError{Op: "create", Path: path.Join(table, name), Err: fs.ErrExist}
+	}
+	return disk.Find(path.Join(table, name))
+}
+
+// Next increments and returns the counter of key. Counters are kept
+// in the .seq table which has no index row and is never listed.
+//
+// Parameters:
+// - key: The name of the counter, usually a form name.
+//
+// Returns:
+// - The next value of the counter starting at 1.
+// - An error if the counter cannot be updated.
+func (disk *SQLite) Next(key string) (int64, error) {
+	table := quote(SEQ_DIR)
+	_, err := disk.db.Exec(`CREATE TABLE IF NOT EXISTS ` + table + ` (name TEXT PRIMARY KEY, value INTEGER NOT NULL)`)
+	if err != nil {
+		return 0, err
+	}
+	var value int64
+	err = disk.db.QueryRow(`INSERT INTO `+table+` (name, value) VALUES (?, 1)
+		ON CONFLICT(name) DO UPDATE SET value = value + 1 RETURNING value`, key).Scan(&value)
+	return value, err
+}
+
+// Remove deletes a row, or a table when given a top level path.
+//
+// Parameters:
+// - filepath: The path to the row or table.
+//
+// Returns:
+// - An error if the row or table cannot be deleted.
+func (disk *SQLite) Remove(filepath string) error {
+	table, name := disk.split(filepath)
+	if table == "" {
+		return fmt.Errorf("invalid sqlite path: %s", filepath)
+	}
+	if !disk.exists(table) {
+		return nil
+	}
+	if name == "" {
+		_, err := disk.db.Exec(`DROP TABLE ` + quote(table))
+		return err
+	}
+	_, err := disk.db.Exec(`DELETE FROM `+quote(table)+` WHERE name = ? OR name LIKE ? ESCAPE '\'`,
+		name, strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(name)+"/%")
+	return err
+}
@@ -0,0 +1,71 @@
+package fs
+
+import (
+	"io/fs"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLite(t *testing.T) {
+	disk, err := OpenSQLite(path.Join(t.TempDir(), "data.db"), "index", "md")
+	assert.NoError(t, err)
+	defer disk.Close()
+
+	// Dump appends the disk extension
+	assert.NoError(t, disk.Dump("contact-us/one", []byte("one")))
+	assert.NoError(t, disk.Dump("contact-us/two", []byte("two")))
+
+	entry, err := disk.Find("contact-us/one")
+	assert.NoError(t, err)
+	assert.Equal(t, "one", entry.Name())
+	assert.Equal(t, "md", entry.Ext())
+	assert.Equal(t, "contact-us/one.md", entry.Path())
+	assert.True(t, entry.IsFile())
+
+	content, err := entry.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, "one", string(content))
+
+	entries, err := disk.List("contact-us")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "two", entries[1].Name())
+
+	// Create refuses to replace existing rows
+	_, err = disk.Create("contact-us/one", []byte("again"))
+	assert.ErrorIs(t, err, fs.ErrExist)
+
+	assert.NoError(t, disk.Append("contact-us/one", []byte(" more")))
+	content, err = disk.Read("contact-us/one.md")
+	assert.NoError(t, err)
+	assert.Equal(t, "one more", string(content))
+
+	assert.NoError(t, disk.Remove("contact-us/one.md"))
+	_, err = disk.Find("contact-us/one")
+	assert.Error(t, err)
+
+	_, err = disk.List("missing")
+	assert.Error(t, err)
+}
+
+func TestSQLiteNext(t *testing.T) {
+	disk, err := OpenSQLite(path.Join(t.TempDir(), "data.db"), "index", "md")
+	assert.NoError(t, err)
+	defer disk.Close()
+
+	for expected := int64(1); expected <= 3; expected++ {
+		value, err := disk.Next("contact-us")
+		assert.NoError(t, err)
+		assert.Equal(t, expected, value)
+	}
+	value, err := disk.Next("sign-up")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), value)
+
+	// The counters table is not listed
+	entries, err := disk.List("")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 0)
+}
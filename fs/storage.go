@@ -0,0 +1,253 @@
+package fs
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+)
+
+// Storage is implemented by every backend a mount can read documents from
+// and write documents to.
+type Storage interface {
+	// Root location of the storage.
+	Root() string
+
+	// Default file extension.
+	Ext() string
+
+	// Default index name.
+	Index() string
+
+	// Default index filename.
+	IName() string
+
+	// Locate a file or directory.
+	Find(string) (*Entry, error)
+
+	// List entries of a directory.
+	List(string) ([]*Entry, error)
+
+	// Read file content.
+	Read(string) ([]byte, error)
+
+	// Replace file content.
+	Dump(string, []byte) error
+
+	// Append content to a file.
+	Append(string, []byte) error
+
+	// Create a new file.
+	Create(string, []byte) (*Entry, error)
+
+	// Remove a file or directory.
+	Remove(string) error
+}
+
+// SEQ_DIR is the directory of a disk root holding counters.
+const SEQ_DIR string = ".seq"
+
+// Counter is implemented by storages that keep persistent monotonic
+// counters, e.g for numbering the submissions of a form.
+type Counter interface {
+	// Increment and return the counter of a key.
+	Next(string) (int64, error)
+}
+
+// FileInfo describes entries of storages that are not backed by an os file.
+type FileInfo struct {
+	name    string    // Base name of the entry.
+	size    int64     // Size of the entry in bytes.
+	modtime time.Time // Modification time of the entry.
+	dir     bool      // Whether the entry is a directory.
+}
+
+// NewFileInfo creates a FileInfo for an entry of a storage.
+//
+// Parameters:
+// - name: The base name of the entry.
+// - size: The size of the entry in bytes.
+// - modtime: The modification time of the entry.
+// - dir: Whether the entry is a directory.
+//
+// Returns:
+// - The FileInfo describing the entry.
+func NewFileInfo(name string, size int64, modtime time.Time, dir bool) fs.FileInfo {
+	return &FileInfo{
+		name:    path.Base(name),
+		size:    size,
+		modtime: modtime,
+		dir:     dir,
+	}
+}
+
+func (info *FileInfo) Name() string {
+	return info.name
+}
+
+func (info *FileInfo) Size() int64 {
+	return info.size
+}
+
+func (info *FileInfo) Mode() fs.FileMode {
+	if info.dir {
+		return fs.ModeDir | 0755
+	}
+	return 0644
+}
+
+func (info *FileInfo) ModTime() time.Time {
+	return info.modtime
+}
+
+func (info *FileInfo) IsDir() bool {
+	return info.dir
+}
+
+func (info *FileInfo) Sys() any {
+	return nil
+}
+
+// NewEntry creates an Entry of a storage from the given FileInfo and path.
+//
+// Parameters:
+// - disk: The storage the entry belongs to.
+// - info: The FileInfo object containing details about the file or directory.
+// - fpath: The path to the entry relative to the storage root.
+//
+// Returns:
+// - A new Entry instance representing the file or directory.
+func NewEntry(disk Storage, info fs.FileInfo, fpath string) Entry {
+	filename := path.Base(fpath)
+	fname, ext := GetNameParts(filename)
+	return Entry{
+		ext:     ext,
+		info:    info,
+		path:    fpath,
+		name:    fname,
+		is_file: !info.IsDir(),
+		disk:    disk,
+	}
+}
+
+// list lists the entries of a directory of a storage backed by an fs.FS,
+// skipping files without the storage extension and directories without
+// an index file.
+//
+// Parameters:
+// - disk: The storage the entries belong to.
+// - fsys: The file system rooted at the storage root.
+// - rpath: The relative path of the directory to list.
+//
+// Returns:
+// - A slice of pointers to Entry objects.
+// - An error if the directory cannot be read.
+func list(disk Storage, fsys fs.FS, rpath string) ([]*Entry, error) {
+	dirpath := clean(rpath)
+	dentries := make([]*Entry, 0)
+
+	entries, err := fs.ReadDir(fsys, dirpath)
+	if err != nil {
+		return dentries, err
+	}
+
+	for _, entry := range entries {
+		// Skip files of writes in progress
+		if IsTemp(entry.Name()) {
+			continue
+		}
+		if entry.IsDir() {
+			indexpath := path.Join(dirpath, entry.Name(), disk.IName())
+			_, err := fs.Stat(fsys, indexpath)
+			if err != nil {
+				continue
+			}
+
+		} else if !entry.Type().IsRegular() || !HasExt(entry.Name(), disk.Ext()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		ppath := path.Join(rpath, entry.Name())
+		dentry := NewEntry(disk, info, ppath)
+		dentries = append(dentries, &dentry)
+	}
+
+	return dentries, nil
+}
+
+// find locates a file or directory of a storage backed by an fs.FS,
+// appending the storage extension to paths without one and requiring
+// directories to have an index file.
+//
+// Parameters:
+// - disk: The storage the entry belongs to.
+// - fsys: The file system rooted at the storage root.
+// - filepath: The relative path to the file or directory.
+//
+// Returns:
+// - A pointer to the Entry if found.
+// - An error if the file or directory cannot be found.
+func find(disk Storage, fsys fs.FS, filepath string) (*Entry, error) {
+	filepath = clean(filepath)
+	index_path := filepath
+
+	_, ext := GetNameParts(filepath)
+	fileinfo, err := fs.Stat(fsys, filepath)
+
+	// Check if file exist
+	if err != nil {
+		// File with ext not found
+		if ext != "" {
+			return nil, err
+		}
+
+		// Append disk extension  and try again
+		index_path = filepath + "." + disk.Ext()
+
+		fileinfo, err = fs.Stat(fsys, index_path)
+		if err != nil {
+			return nil, err
+		}
+		// Reject directory with extension
+		// not something i feel like will be usefull
+		if fileinfo.IsDir() {
+			return nil, fmt.Errorf("index file not found: %s", filepath)
+		}
+
+		// Found file by appending ext
+		filepath = index_path
+	}
+
+	if fileinfo.IsDir() {
+		// Check dir for index file
+		if filepath == "." {
+			index_path = disk.IName()
+		} else {
+			index_path = path.Join(filepath, disk.IName())
+		}
+		_, err := fs.Stat(fsys, index_path)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	dentry := NewEntry(disk, fileinfo, filepath)
+
+	return &dentry, nil
+}
+
+// clean converts a storage path into a valid fs.FS path.
+func clean(filepath string) string {
+	filepath = path.Clean(filepath)
+	filepath = strings.TrimLeft(filepath, "/")
+	filepath = strings.TrimLeft(filepath, `\`)
+	if filepath == "" || filepath == "/" || filepath == `\` {
+		filepath = "."
+	}
+	return filepath
+}
@@ -0,0 +1,198 @@
+package fs
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watcher reports changes to files and directory trees. Events are
+// debounced so an editor saving several files, or writing a file
+// through a temporary one, results in a single report.
+type Watcher struct {
+	watcher *fsnotify.Watcher
+	delay   time.Duration
+	dirs    []string        // Directory trees watched recursively
+	files   map[string]bool // Single files watched through their directory
+	lock    sync.Mutex
+	changed map[string]bool
+	timer   *time.Timer
+}
+
+// Watch starts watching paths. Directories are watched recursively,
+// including directories created later on, and files are watched
+// through their parent directory so editors replacing them on save
+// are noticed. Missing paths are skipped.
+//
+// Parameters:
+// - delay: How long to wait for more events before reporting changes.
+// - fn: Called with the sorted changed paths, or with a watch error.
+// - paths: The files and directories to watch.
+//
+// Returns:
+// - The Watcher, closed with Close.
+// - An error if the paths cannot be watched.
+func Watch(delay time.Duration, fn func(changed []string, err error), paths ...string) (*Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		watcher: watcher,
+		delay:   delay,
+		dirs:    make([]string, 0),
+		files:   make(map[string]bool),
+		changed: make(map[string]bool),
+	}
+
+	for _, ppath := range paths {
+		full, err := filepath.Abs(ppath)
+		if err != nil {
+			watcher.Close()
+			return nil, err
+		}
+		stats, err := os.Stat(full)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			watcher.Close()
+			return nil, err
+		}
+
+		if stats.IsDir() {
+			w.dirs = append(w.dirs, full)
+			err = w.add(full)
+		} else {
+			w.files[full] = true
+			err = watcher.Add(filepath.Dir(full))
+		}
+		if err != nil {
+			watcher.Close()
+			return nil, err
+		}
+	}
+
+	go w.run(fn)
+	return w, nil
+}
+
+// Close stops watching.
+func (w *Watcher) Close() error {
+	w.lock.Lock()
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.lock.Unlock()
+	return w.watcher.Close()
+}
+
+// add watches dir and the directories below it.
+func (w *Watcher) add(dir string) error {
+	return filepath.WalkDir(dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// Removed while walking
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		// Dot directories hold internal state e.g counters and queues
+		if name != dir && strings.HasPrefix(entry.Name(), ".") {
+			return filepath.SkipDir
+		}
+		return w.watcher.Add(name)
+	})
+}
+
+// watched reports whether a change to name must be reported,
+// changes to the dot files and directories of a tree are not.
+func (w *Watcher) watched(name string) bool {
+	if IsTemp(filepath.Base(name)) {
+		return false
+	}
+	if w.files[name] {
+		return true
+	}
+	for _, dir := range w.dirs {
+		if within(dir, name) && !hidden(dir, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// hidden reports whether name is a dot file or directory of dir
+// or below one.
+func hidden(dir string, name string) bool {
+	rel, err := filepath.Rel(dir, name)
+	if err != nil || rel == "." {
+		return false
+	}
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		if strings.HasPrefix(part, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// run collects events until the watcher is closed.
+func (w *Watcher) run(fn func([]string, error)) {
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if !w.watched(event.Name) {
+				continue
+			}
+			if event.Has(fsnotify.Create) {
+				if stats, err := os.Stat(event.Name); err == nil && stats.IsDir() {
+					if err := w.add(event.Name); err != nil {
+						fn(nil, err)
+					}
+				}
+			}
+			w.queue(event.Name, fn)
+
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			fn(nil, err)
+		}
+	}
+}
+
+// queue records a change and reports the changes once
+// no other event happened for the watcher delay
+func (w *Watcher) queue(name string, fn func([]string, error)) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.changed[name] = true
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.timer = time.AfterFunc(w.delay, func() {
+		w.lock.Lock()
+		changed := make([]string, 0, len(w.changed))
+		for name := range w.changed {
+			changed = append(changed, name)
+		}
+		w.changed = make(map[string]bool)
+		w.lock.Unlock()
+
+		sort.Strings(changed)
+		fn(changed, nil)
+	})
+}
@@ -0,0 +1,66 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatch(t *testing.T) {
+	root := t.TempDir()
+	other := t.TempDir()
+	dir := filepath.Join(root, "content")
+	site := filepath.Join(root, "index.md")
+	os.Mkdir(dir, 0755)
+	os.WriteFile(site, []byte("site"), 0644)
+
+	reports := make(chan []string, 10)
+	watcher, err := Watch(50*time.Millisecond, func(changed []string, err error) {
+		assert.NoError(t, err)
+		reports <- changed
+	}, dir, site, filepath.Join(root, "missing"))
+	assert.NoError(t, err)
+	defer watcher.Close()
+
+	wait := func() []string {
+		select {
+		case changed := <-reports:
+			return changed
+		case <-time.After(2 * time.Second):
+			t.Fatal("no change reported")
+		}
+		return nil
+	}
+
+	// Several writes are reported at once
+	os.WriteFile(filepath.Join(dir, "one.md"), []byte("one"), 0644)
+	os.WriteFile(filepath.Join(dir, "two.md"), []byte("two"), 0644)
+	assert.Equal(t, []string{filepath.Join(dir, "one.md"), filepath.Join(dir, "two.md")}, wait())
+
+	// Directories created later are watched
+	os.Mkdir(filepath.Join(dir, "posts"), 0755)
+	wait()
+	os.WriteFile(filepath.Join(dir, "posts", "three.md"), []byte("three"), 0644)
+	assert.Equal(t, []string{filepath.Join(dir, "posts", "three.md")}, wait())
+
+	// Files are replaced through a temporary file on save, only
+	// the watched file is reported and not its neighbours
+	os.WriteFile(filepath.Join(root, "server.log"), []byte("log"), 0644)
+	assert.NoError(t, WriteFile(site, []byte("saved"), 0644, false))
+	assert.Equal(t, []string{site}, wait())
+
+	// Dot directories hold internal state and are not reported
+	os.MkdirAll(filepath.Join(dir, ".queue", "inner"), 0755)
+	os.WriteFile(filepath.Join(dir, ".queue", "one.md"), []byte("no"), 0644)
+	os.WriteFile(filepath.Join(dir, ".queue", "inner", "two.md"), []byte("no"), 0644)
+
+	os.WriteFile(filepath.Join(other, "elsewhere.md"), []byte("no"), 0644)
+	select {
+	case changed := <-reports:
+		t.Fatalf("unexpected change: %v", changed)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
@@ -1,7 +1,13 @@
 package julien
 
 import (
+	"fmt"
 	"julien/driver"
+	"julien/fs"
+	"julien/limit"
+	"julien/mail"
+	"julien/markdown"
+	"julien/utils"
 	"os"
 	"path"
 
@@ -22,11 +28,34 @@ type Logger struct {
 }
 
 type MountPoint struct {
-	Path   string   `yaml:"path"`
-	Ext    string   `yaml:"ext"`
-	Index  string   `yaml:"index"`
-	Driver string   `yaml:"driver"`
-	Assets []string `yaml:"assets"`
+	Path    string   `yaml:"path"`
+	Ext     string   `yaml:"ext"`
+	Index   string   `yaml:"index"`
+	Driver  string   `yaml:"driver"`
+	Storage string   `yaml:"storage"`
+	Rotate  string   `yaml:"rotate"`
+	Fsync   bool     `yaml:"fsync"`
+	Assets  []string `yaml:"assets"`
+}
+
+// Mount opens the storage backend selected by the mount storage key.
+func (mp *MountPoint) Mount() (fs.Storage, error) {
+	switch mp.Storage {
+	case "", "disk":
+		return fs.Mount(mp.Path, mp.Index, mp.Ext).Fsync(mp.Fsync), nil
+
+	case "sqlite":
+		return fs.OpenSQLite(mp.Path, mp.Index, mp.Ext)
+
+	case "memory":
+		return fs.NewMemory(mp.Index, mp.Ext), nil
+
+	case "jsonl":
+		return fs.MountLines(mp.Path, mp.Index, mp.Ext, mp.Rotate), nil
+
+	default:
+		return nil, fmt.Errorf("storage not found: %s", mp.Storage)
+	}
 }
 
 type Meta struct {
@@ -38,6 +67,34 @@ type Meta struct {
 type Template struct {
 	Path string `yaml:"path"`
 	Name string `yaml:"name"`
+	// Templates under path searched before name
+	Overrides []string `yaml:"overrides"`
+}
+
+// Preview signs links showing unpublished pages to editors
+type Preview struct {
+	Secret string `yaml:"secret"` // Previews are disabled without a secret
+}
+
+// Admin guards the routes of site editors e.g downloads of form files
+type Admin struct {
+	Users map[string]string `yaml:"users"` // Passwords or bcrypt hashes by user name
+}
+
+// Proxy reads the client ip of requests from the header of a reverse
+// proxy, e.g for rate limits keyed by ip
+type Proxy struct {
+	Header  string   `yaml:"header"`  // e.g X-Forwarded-For, the connection ip is used without it
+	Trusted []string `yaml:"trusted"` // Ips or ranges allowed to set the header, every client if empty
+}
+
+// Taxonomy lists pages by the terms of a frontmatter key
+// under /<name> and /<name>/<term>
+type Taxonomy struct {
+	Key    string `yaml:"key"`    // Frontmatter key, defaults to the taxonomy name
+	View   string `yaml:"view"`   // View of a term page, defaults to term
+	Terms  string `yaml:"terms"`  // View of the terms page, defaults to terms
+	Layout string `yaml:"layout"` // Defaults to the layout of the home page
 }
 
 type Julien struct {
@@ -47,6 +104,39 @@ type Julien struct {
 	Template Template    `yaml:"template"`
 	Static   StaticMount `yaml:"static"`
 	Logger   Logger      `yaml:"logger"`
+	// Origins allowed to post forms cross site e.g
+	// the host of a site exported with julien build
+	Origins []string `yaml:"origins"`
+	// Taxonomies by name e.g tags or categories
+	Taxonomies map[string]Taxonomy `yaml:"taxonomies"`
+	Preview    Preview             `yaml:"preview"`
+	// Markdown extensions of the pages of every template engine
+	Markdown markdown.Options `yaml:"markdown"`
+	// Admin routes are disabled without users
+	Admin Admin `yaml:"admin"`
+	// Size limit of request bodies e.g 32MB, defaults to 4MB
+	BodyLimit interface{} `yaml:"body_limit"`
+	// Smtp server of the form notifications
+	SMTP mail.Config `yaml:"smtp"`
+	// Signs the spam tokens of forms, random on each start if empty
+	Secret string `yaml:"secret"`
+	// Submissions of each form by key, forms override it with their rate_limit key
+	RateLimit limit.Config `yaml:"rate_limit"`
+	// Reverse proxy in front of julien
+	Proxy Proxy `yaml:"proxy"`
+}
+
+// BodySize returns the size limit of request bodies in bytes,
+// zero without a body_limit
+func (j *Julien) BodySize() (int, error) {
+	if j.BodyLimit == nil {
+		return 0, nil
+	}
+	size, err := utils.ParseSize(j.BodyLimit)
+	if err != nil {
+		return 0, fmt.Errorf("body_limit: %w", err)
+	}
+	return int(size), nil
 }
 
 func (j *Julien) DataPath() string {
@@ -73,10 +163,32 @@ func (j *Julien) TemplateName() string {
 	return j.Template.Name
 }
 
+func (j *Julien) TemplateOverrides() []string {
+	return j.Template.Overrides
+}
+
 func (j *Julien) ContentAssets() []string {
 	return j.Content.Assets
 }
 
+// Taxonomy returns the taxonomy of a name with its defaults applied
+func (j *Julien) Taxonomy(name string) (Taxonomy, bool) {
+	taxonomy, ok := j.Taxonomies[name]
+	if !ok {
+		return taxonomy, false
+	}
+	if taxonomy.Key == "" {
+		taxonomy.Key = name
+	}
+	if taxonomy.View == "" {
+		taxonomy.View = "term"
+	}
+	if taxonomy.Terms == "" {
+		taxonomy.Terms = "terms"
+	}
+	return taxonomy, true
+}
+
 func DefaultSite() Site {
 	return Site{
 		body: "",
@@ -94,11 +206,12 @@ func CreateDefaultTemplate(path string) Template {
 
 func CreateDefaultMount(path string) MountPoint {
 	return MountPoint{
-		Ext:    "md",
-		Path:   path,
-		Index:  "index",
-		Driver: "yaml-md",
-		Assets: []string{"png", "jpg", "jpeg", "gif", "mp4", "webm"},
+		Ext:     "md",
+		Path:    path,
+		Index:   "index",
+		Driver:  "yaml-md",
+		Storage: "disk",
+		Assets:  []string{"png", "jpg", "jpeg", "gif", "mp4", "webm"},
 	}
 }
 
@@ -116,6 +229,7 @@ func DefaultJulien() Julien {
 		Template: CreateDefaultTemplate("templates"),
 		Static:   CreateStaticMount("static"),
 		Logger:   Logger{Format: "[${ip}]:${method} ${path} - ${status}"},
+		Markdown: markdown.Default(),
 	}
 }
 
@@ -150,3 +264,16 @@ func LoadConfig(path string, j *Julien) {
 	strconfig := ReadFile(path)
 	LoadConfigFromStr(strconfig, j)
 }
+
+// FindConfig reads the config file at path over the default config.
+func FindConfig(path string) (*Julien, error) {
+	cbytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	j := DefaultJulien()
+	if err := yaml.Unmarshal(cbytes, &j); err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
@@ -38,6 +38,18 @@ func LoadSite(ppath string, s *Site) {
 	s.body = body
 }
 
+// ParseSite parses the raw content of a site file into s.
+func ParseSite(raw []byte, s *Site) error {
+	yamler := driver.Yaml{}
+	frontmatter, body, err := yamler.Parse(raw)
+	if err != nil {
+		return err
+	}
+	s.meta = *frontmatter
+	s.body = body
+	return nil
+}
+
 func FindSite(ppath string) (*Site, error) {
 	yamler := driver.Yaml{}
 	ppath = path.Clean(ppath)
@@ -0,0 +1,310 @@
+package limit
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"julien/fs"
+	"math"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// STATE_DIR is the directory of the limiter state in the data mount
+const STATE_DIR string = ".ratelimit"
+
+// STATE_EXT is the extension of the state files whatever the storage
+const STATE_EXT string = "json"
+
+// WINDOW is the window of a rule unless configured
+const WINDOW time.Duration = time.Hour
+
+// Keys of the submissions a rule counts, fields are keyed
+// by their name after an @ e.g @email
+
+const KEY_IP string = "ip"
+
+const KEY_SESSION string = "session"
+
+// Rule limits the submissions of a form by key
+type Rule struct {
+	Limit  int // Submissions per window, zero disables the rule
+	Window time.Duration
+	Key    string // ip, session or @field
+}
+
+// Config is the rule of every form, set under the rate_limit key
+// of julien.yaml e.g rate_limit: {limit: 5, window: 1h, key: ip}
+type Config struct {
+	Limit  int    `yaml:"limit"`
+	Window string `yaml:"window"`
+	Key    string `yaml:"key"`
+}
+
+// Rule returns the rule of the config.
+//
+// Returns:
+// - The Rule, disabled without a limit.
+// - An error for a malformed window or key.
+func (config Config) Rule() (Rule, error) {
+	spec := map[interface{}]interface{}{"limit": config.Limit, "key": config.Key}
+	if config.Window != "" {
+		spec["window"] = config.Window
+	}
+	return Parse(spec, Rule{Window: WINDOW, Key: KEY_IP})
+}
+
+// Parse reads the rate_limit key of a form over the rule of the config.
+//
+// Parameters:
+// - value: The value of the rate_limit key, nil keeps base and false disables it.
+// - base: The rule of the config.
+//
+// Returns:
+// - The Rule.
+// - An error for a malformed rate_limit key.
+func Parse(value interface{}, base Rule) (Rule, error) {
+	rule := base
+	switch value := value.(type) {
+	case nil:
+		return rule, nil
+	case bool:
+		if !value {
+			rule.Limit = 0
+		}
+		return rule, nil
+	case map[interface{}]interface{}:
+		if limit, ok := value["limit"]; ok {
+			if rule.Limit, ok = limit.(int); !ok || rule.Limit < 0 {
+				return rule, fmt.Errorf("rate_limit limit must be a positive number")
+			}
+		}
+		switch window := value["window"].(type) {
+		case nil:
+		case int:
+			rule.Window = time.Duration(window) * time.Second
+		case string:
+			duration, err := time.ParseDuration(window)
+			if err != nil {
+				return rule, fmt.Errorf("rate_limit window: %w", err)
+			}
+			rule.Window = duration
+		default:
+			return rule, fmt.Errorf("rate_limit window: invalid duration: %v", window)
+		}
+		if rule.Window <= 0 {
+			rule.Window = WINDOW
+		}
+		if key, ok := value["key"].(string); ok && key != "" {
+			rule.Key = key
+		}
+		if rule.Key == "" {
+			rule.Key = KEY_IP
+		}
+		if rule.Key != KEY_IP && rule.Key != KEY_SESSION && (!strings.HasPrefix(rule.Key, "@") || len(rule.Key) == 1) {
+			return rule, fmt.Errorf("rate_limit key must be ip, session or @field: %s", rule.Key)
+		}
+		return rule, nil
+	default:
+		return rule, fmt.Errorf("rate_limit must be a map or false")
+	}
+}
+
+// Enabled reports whether the rule limits submissions
+func (rule Rule) Enabled() bool {
+	return rule.Limit > 0
+}
+
+// Field returns the submitted field of the key of the rule, empty
+// for rules keyed by ip or session
+func (rule Rule) Field() string {
+	return strings.TrimPrefix(rule.Key, "@")
+}
+
+// seconds returns the window of the rule in whole seconds
+func (rule Rule) seconds() int64 {
+	return max(1, int64(rule.Window/time.Second))
+}
+
+// counter counts the submissions of a key in the current
+// and the previous window
+type counter struct {
+	Start    int64 `json:"start"` // Unix time of the current window
+	Count    int   `json:"count"`
+	Previous int   `json:"previous"`
+}
+
+// Limiter counts the submissions of forms by key in a storage so
+// the counts survive restarts, it is safe for concurrent use
+type Limiter struct {
+	storage fs.Storage
+	lock    sync.Mutex
+	scopes  map[string]map[string]*counter // Counters of each scope by hashed key
+}
+
+// New creates a Limiter keeping its state in the STATE_DIR of a storage.
+//
+// Parameters:
+// - storage: The storage of the state, usually the data mount.
+//
+// Returns:
+// - The Limiter.
+func New(storage fs.Storage) *Limiter {
+	return &Limiter{storage: storage, scopes: make(map[string]map[string]*counter)}
+}
+
+// Allow counts a submission of a key if the rule allows it, the
+// count of a window is weighed with the count of the previous
+// window over the time it overlaps.
+//
+// Parameters:
+// - scope: What the rule limits e.g the form name.
+// - rule: The rule of the scope.
+// - key: The value of the rule key e.g an ip.
+// - now: The time of the submission.
+//
+// Returns:
+// - Whether the submission is allowed.
+// - How long until a submission is allowed, zero if it is.
+// - An error if the state cannot be read or stored, the submission is then allowed.
+func (l *Limiter) Allow(scope string, rule Rule, key string, now time.Time) (bool, time.Duration, error) {
+	if !rule.Enabled() {
+		return true, 0, nil
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	counters, err := l.load(scope)
+	if err != nil {
+		return true, 0, err
+	}
+
+	hashed := hash(rule.Key + "\n" + key)
+	window := rule.seconds()
+	current := now.Unix() - now.Unix()%window
+	count, ok := counters[hashed]
+	if !ok {
+		count = &counter{Start: current}
+		counters[hashed] = count
+	}
+	switch {
+	case count.Start == current:
+	case count.Start == current-window:
+		count.Previous, count.Count, count.Start = count.Count, 0, current
+	default:
+		count.Previous, count.Count, count.Start = 0, 0, current
+	}
+
+	elapsed := float64(now.Unix()-current) / float64(window)
+	weighed := float64(count.Previous)*(1-elapsed) + float64(count.Count)
+	if weighed+1 > float64(rule.Limit) {
+		return false, retry(count, rule.Limit, window, now), nil
+	}
+	count.Count++
+	return true, 0, l.save(scope, counters, current, window)
+}
+
+// Refund takes back a submission Allow counted, e.g one failing
+// to be stored, so it does not count against its key.
+//
+// Parameters:
+// - scope: What the rule limits e.g the form name.
+// - rule: The rule of the scope.
+// - key: The value of the rule key e.g an ip.
+// - at: The time the submission was allowed at.
+//
+// Returns:
+// - An error if the state cannot be read or stored.
+func (l *Limiter) Refund(scope string, rule Rule, key string, at time.Time) error {
+	if !rule.Enabled() {
+		return nil
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	counters, err := l.load(scope)
+	if err != nil {
+		return err
+	}
+	count, ok := counters[hash(rule.Key+"\n"+key)]
+	if !ok {
+		return nil
+	}
+	window := rule.seconds()
+	counted := at.Unix() - at.Unix()%window
+	switch {
+	case count.Start == counted && count.Count > 0:
+		count.Count--
+	case count.Start == counted+window && count.Previous > 0:
+		count.Previous--
+	default:
+		return nil
+	}
+	return l.save(scope, counters, count.Start, window)
+}
+
+// retry returns how long until the weighed count of a full counter
+// drops enough for one more submission, in the current window if the
+// previous count weighs enough or else in the next one
+func retry(count *counter, limit int, window int64, now time.Time) time.Duration {
+	at := count.Start + window
+	if free := limit - count.Count - 1; free >= 0 && count.Previous > 0 {
+		// previous * (1 - elapsed) + count + 1 <= limit
+		elapsed := 1 - float64(free)/float64(count.Previous)
+		at = count.Start + int64(math.Ceil(elapsed*float64(window)))
+	} else if count.Count > limit-1 {
+		// count * (1 - elapsed) + 1 <= limit in the next window
+		elapsed := 1 - float64(limit-1)/float64(count.Count)
+		at = count.Start + window + int64(math.Ceil(elapsed*float64(window)))
+	}
+	return time.Duration(max(1, at-now.Unix())) * time.Second
+}
+
+// load reads the counters of a scope once
+func (l *Limiter) load(scope string) (map[string]*counter, error) {
+	if counters, ok := l.scopes[scope]; ok {
+		return counters, nil
+	}
+	counters := make(map[string]*counter)
+	content, err := l.storage.Read(l.file(scope))
+	if err != nil && !errors.Is(err, iofs.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(content, &counters); err != nil {
+			return nil, fmt.Errorf("rate limit %s: %w", scope, err)
+		}
+	}
+	l.scopes[scope] = counters
+	return counters, nil
+}
+
+// save stores the counters of a scope dropping the ones
+// older than the previous window
+func (l *Limiter) save(scope string, counters map[string]*counter, current int64, window int64) error {
+	for key, count := range counters {
+		if count.Start < current-window {
+			delete(counters, key)
+		}
+	}
+	content, err := json.Marshal(counters)
+	if err != nil {
+		return err
+	}
+	return l.storage.Dump(l.file(scope), content)
+}
+
+// file returns the path of the state of a scope, a json file
+// and not a document of the storage extension
+func (l *Limiter) file(scope string) string {
+	return path.Join(STATE_DIR, fs.CleanName(scope)+"."+STATE_EXT)
+}
+
+// hash keeps the keys of the state e.g addresses unreadable
+func hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return base64.RawURLEncoding.EncodeToString(sum[:16])
+}
@@ -0,0 +1,101 @@
+package limit
+
+import (
+	"julien/fs"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	base, err := Config{Limit: 5, Window: "10m"}.Rule()
+	assert.NoError(t, err)
+	assert.Equal(t, Rule{Limit: 5, Window: 10 * time.Minute, Key: KEY_IP}, base)
+
+	off, err := Config{}.Rule()
+	assert.NoError(t, err)
+	assert.False(t, off.Enabled())
+
+	rule, err := Parse(nil, base)
+	assert.NoError(t, err)
+	assert.Equal(t, base, rule)
+	rule, err = Parse(false, base)
+	assert.NoError(t, err)
+	assert.False(t, rule.Enabled())
+	rule, err = Parse(map[interface{}]interface{}{"limit": 2, "window": 60, "key": "@email"}, base)
+	assert.NoError(t, err)
+	assert.Equal(t, Rule{Limit: 2, Window: time.Minute, Key: "@email"}, rule)
+	assert.Equal(t, "email", rule.Field())
+
+	_, err = Parse(map[interface{}]interface{}{"key": "cookie"}, base)
+	assert.Error(t, err)
+	_, err = Parse(map[interface{}]interface{}{"window": "often"}, base)
+	assert.Error(t, err)
+	_, err = Parse(map[interface{}]interface{}{"limit": -1}, base)
+	assert.Error(t, err)
+	_, err = Config{Limit: 1, Key: "@"}.Rule()
+	assert.Error(t, err)
+}
+
+func TestAllow(t *testing.T) {
+	storage := fs.NewMemory("index", "md")
+	limiter := New(storage)
+	rule := Rule{Limit: 2, Window: time.Minute, Key: KEY_IP}
+	start := time.Unix(1714557600, 0) // Start of a window
+
+	for i := 0; i < 2; i++ {
+		ok, _, err := limiter.Allow("contact", rule, "1.2.3.4", start)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	}
+	ok, wait, err := limiter.Allow("contact", rule, "1.2.3.4", start.Add(10*time.Second))
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 80*time.Second, wait)
+
+	// Other keys and scopes have their own counts
+	ok, _, _ = limiter.Allow("contact", rule, "5.6.7.8", start)
+	assert.True(t, ok)
+	ok, _, _ = limiter.Allow("sign-up", rule, "1.2.3.4", start)
+	assert.True(t, ok)
+	ok, _, _ = limiter.Allow("contact", Rule{}, "1.2.3.4", start)
+	assert.True(t, ok)
+
+	// The previous window weighs over the next one
+	ok, wait, _ = limiter.Allow("contact", rule, "1.2.3.4", start.Add(70*time.Second))
+	assert.False(t, ok)
+	assert.Equal(t, 20*time.Second, wait)
+	ok, _, _ = limiter.Allow("contact", rule, "1.2.3.4", start.Add(90*time.Second))
+	assert.True(t, ok)
+
+	// Counts survive a restart and keys are not stored
+	content, err := storage.Read(STATE_DIR + "/contact.json")
+	assert.NoError(t, err)
+	assert.False(t, strings.Contains(string(content), "1.2.3.4"))
+	limiter = New(storage)
+	ok, wait, _ = limiter.Allow("contact", rule, "1.2.3.4", start.Add(91*time.Second))
+	assert.False(t, ok)
+	assert.Equal(t, 29*time.Second, wait)
+}
+
+func TestRefund(t *testing.T) {
+	limiter := New(fs.NewMemory("index", "md"))
+	rule := Rule{Limit: 1, Window: time.Minute, Key: KEY_IP}
+	start := time.Unix(1714557600, 0)
+
+	ok, _, _ := limiter.Allow("contact", rule, "1.2.3.4", start)
+	assert.True(t, ok)
+	assert.NoError(t, limiter.Refund("contact", rule, "1.2.3.4", start))
+	ok, _, _ = limiter.Allow("contact", rule, "1.2.3.4", start.Add(time.Second))
+	assert.True(t, ok)
+	ok, _, _ = limiter.Allow("contact", rule, "1.2.3.4", start.Add(2*time.Second))
+	assert.False(t, ok)
+
+	// Refunds of the previous window lighten its weight
+	assert.NoError(t, limiter.Refund("contact", rule, "1.2.3.4", start.Add(time.Second)))
+	ok, _, _ = limiter.Allow("contact", rule, "1.2.3.4", start.Add(61*time.Second))
+	assert.True(t, ok)
+	assert.NoError(t, limiter.Refund("contact", rule, "5.6.7.8", start))
+}
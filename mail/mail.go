@@ -0,0 +1,237 @@
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PORT is the smtp port unless configured
+const PORT int = 25
+
+// TIMEOUT bounds the connection to the smtp server
+const TIMEOUT time.Duration = 30 * time.Second
+
+// Config is the smtp server of a site, set under
+// the smtp key of julien.yaml
+type Config struct {
+	Host     string `yaml:"host"` // Mail is disabled without a host
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"` // Sender e.g Julien <noreply@example.com>
+	TLS      bool   `yaml:"tls"`  // Implicit tls e.g port 465, starttls is used when offered otherwise
+}
+
+// Message is a mail to send, the body is html if HTML is set
+type Message struct {
+	To      []string
+	ReplyTo string
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Mailer sends messages through the smtp server of its config,
+// it is safe for concurrent use
+type Mailer struct {
+	config Config
+}
+
+// New creates a Mailer.
+//
+// Parameters:
+// - config: The smtp server to send through.
+//
+// Returns:
+// - The Mailer.
+// - An error if the config has no host or its sender is not an address.
+func New(config Config) (*Mailer, error) {
+	if config.Host == "" {
+		return nil, errors.New("smtp host not configured")
+	}
+	if _, err := netmail.ParseAddress(config.From); err != nil {
+		return nil, fmt.Errorf("smtp from: %w", err)
+	}
+	if config.Port == 0 {
+		config.Port = PORT
+	}
+	return &Mailer{config: config}, nil
+}
+
+// Send delivers a message to its recipients.
+//
+// Parameters:
+// - msg: The message to send.
+//
+// Returns:
+// - An error if a recipient is not an address or the server refuses the message.
+func (m *Mailer) Send(msg Message) error {
+	from, _ := netmail.ParseAddress(m.config.From)
+	recipients := make([]string, 0, len(msg.To))
+	for _, to := range msg.To {
+		address, err := netmail.ParseAddress(to)
+		if err != nil {
+			return fmt.Errorf("mail to %q: %w", to, err)
+		}
+		recipients = append(recipients, address.Address)
+	}
+	if len(recipients) == 0 {
+		return errors.New("mail without recipients")
+	}
+	content, err := msg.Bytes(m.config.From, time.Now())
+	if err != nil {
+		return err
+	}
+
+	client, err := m.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok && !m.config.TLS {
+		if err := client.StartTLS(&tls.Config{ServerName: m.config.Host}); err != nil {
+			return err
+		}
+	}
+	if m.config.Username != "" {
+		auth := smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, to := range recipients {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	data, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := data.Write(content); err != nil {
+		return err
+	}
+	if err := data.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// dial connects to the smtp server of the config
+func (m *Mailer) dial() (*smtp.Client, error) {
+	addr := net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port))
+	dialer := &net.Dialer{Timeout: TIMEOUT}
+	var conn net.Conn
+	var err error
+	if m.config.TLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: m.config.Host})
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(TIMEOUT))
+	client, err := smtp.NewClient(conn, m.config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// Bytes writes the message with its headers, the body is
+// quoted printable utf-8.
+//
+// Parameters:
+// - from: The sender of the message.
+// - date: The date of the message.
+//
+// Returns:
+// - The message.
+// - An error if an address is malformed.
+func (msg *Message) Bytes(from string, date time.Time) ([]byte, error) {
+	sender, err := netmail.ParseAddress(from)
+	if err != nil {
+		return nil, err
+	}
+	to := make([]string, 0, len(msg.To))
+	for _, recipient := range msg.To {
+		address, err := netmail.ParseAddress(recipient)
+		if err != nil {
+			return nil, err
+		}
+		to = append(to, address.String())
+	}
+
+	out := bytes.NewBuffer(nil)
+	header := func(key string, value string) {
+		out.WriteString(key + ": " + value + "\r\n")
+	}
+	header("From", sender.String())
+	header("To", strings.Join(to, ", "))
+	if msg.ReplyTo != "" {
+		address, err := netmail.ParseAddress(msg.ReplyTo)
+		if err != nil {
+			return nil, err
+		}
+		header("Reply-To", address.String())
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", oneline(msg.Subject)))
+	header("Date", date.Format(time.RFC1123Z))
+	header("Message-ID", "<"+id()+"@"+domain(sender.Address)+">")
+	header("MIME-Version", "1.0")
+	if msg.HTML != "" {
+		header("Content-Type", "text/html; charset=utf-8")
+	} else {
+		header("Content-Type", "text/plain; charset=utf-8")
+	}
+	header("Content-Transfer-Encoding", "quoted-printable")
+	out.WriteString("\r\n")
+
+	body := msg.Text
+	if msg.HTML != "" {
+		body = msg.HTML
+	}
+	writer := quotedprintable.NewWriter(out)
+	if _, err := writer.Write([]byte(body)); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// oneline keeps header values on a single line
+func oneline(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
+
+func id() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+func domain(address string) string {
+	if _, host, ok := strings.Cut(address, "@"); ok {
+		return host
+	}
+	return "localhost"
+}
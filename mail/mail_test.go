@@ -0,0 +1,129 @@
+package mail
+
+import (
+	"bufio"
+	"mime/quotedprintable"
+	"net"
+	netmail "net/mail"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// sink is a local smtp server keeping the messages it receives
+type sink struct {
+	listener net.Listener
+	messages chan string
+	rcpts    chan []string
+}
+
+func newSink(t *testing.T) *sink {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	s := &sink{listener: listener, messages: make(chan string, 10), rcpts: make(chan []string, 10)}
+	go s.serve()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *sink) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *sink) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.session(conn)
+	}
+}
+
+func (s *sink) session(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) {
+		conn.Write([]byte(line + "\r\n"))
+	}
+	reply("220 sink")
+	rcpts := make([]string, 0)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 sink")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			rcpts = append(rcpts, strings.Trim(strings.TrimSpace(line)[8:], "<>"))
+			reply("250 ok")
+		case command == "DATA":
+			reply("354 go ahead")
+			data := strings.Builder{}
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			s.messages <- data.String()
+			s.rcpts <- rcpts
+			reply("250 queued")
+		case command == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	s := newSink(t)
+	_, err := New(Config{From: "noreply@example.com"})
+	assert.Error(t, err)
+	_, err = New(Config{Host: "127.0.0.1", From: "not an address"})
+	assert.Error(t, err)
+
+	mailer, err := New(Config{Host: "127.0.0.1", Port: s.port(), From: "Julien <noreply@example.com>"})
+	assert.NoError(t, err)
+	err = mailer.Send(Message{
+		To:      []string{"Sales <sales@example.com>", "ops@example.com"},
+		ReplyTo: "bob@mail.com",
+		Subject: "New contact\r\nBcc: evil@example.com",
+		HTML:    "<p>Héllo</p>",
+	})
+	assert.NoError(t, err)
+
+	select {
+	case data := <-s.messages:
+		assert.Equal(t, []string{"sales@example.com", "ops@example.com"}, <-s.rcpts)
+		msg, err := netmail.ReadMessage(strings.NewReader(data))
+		assert.NoError(t, err)
+		assert.Equal(t, `"Julien" <noreply@example.com>`, msg.Header.Get("From"))
+		assert.Equal(t, `"Sales" <sales@example.com>, <ops@example.com>`, msg.Header.Get("To"))
+		assert.Equal(t, "<bob@mail.com>", msg.Header.Get("Reply-To"))
+		assert.Equal(t, "New contact Bcc: evil@example.com", msg.Header.Get("Subject"))
+		assert.Empty(t, msg.Header.Get("Bcc"))
+		assert.Equal(t, "text/html; charset=utf-8", msg.Header.Get("Content-Type"))
+		body := strings.Builder{}
+		_, err = bufio.NewReader(quotedprintable.NewReader(msg.Body)).WriteTo(&body)
+		assert.NoError(t, err)
+		assert.Equal(t, "<p>Héllo</p>", strings.TrimSpace(body.String()))
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received on port " + strconv.Itoa(s.port()))
+	}
+
+	assert.Error(t, mailer.Send(Message{To: []string{"bad"}, Text: "hi"}))
+	assert.Error(t, mailer.Send(Message{Text: "hi"}))
+}
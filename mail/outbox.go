@@ -0,0 +1,72 @@
+package mail
+
+import "sync"
+
+// OUTBOX is the number of messages waiting to be sent at most
+const OUTBOX int = 64
+
+// SENDERS is the number of messages sent at once
+const SENDERS int = 2
+
+// Outbox sends messages in the background through a bounded queue
+// and a fixed number of senders, it is safe for concurrent use
+type Outbox struct {
+	lock     sync.Mutex
+	messages chan Message // Nil once closed
+}
+
+// NewOutbox creates an Outbox and starts its senders.
+//
+// Parameters:
+// - mailer: The Mailer sending the messages.
+// - size: The number of messages waiting to be sent at most.
+// - senders: The number of messages sent at once.
+// - report: Called with the messages that failed to be sent.
+//
+// Returns:
+// - The Outbox.
+func NewOutbox(mailer *Mailer, size int, senders int, report func(Message, error)) *Outbox {
+	messages := make(chan Message, max(size, 0))
+	for range max(senders, 1) {
+		go func() {
+			for msg := range messages {
+				if err := mailer.Send(msg); err != nil {
+					report(msg, err)
+				}
+			}
+		}()
+	}
+	return &Outbox{messages: messages}
+}
+
+// Post queues a message without waiting for it to be sent.
+//
+// Parameters:
+// - msg: The message to send.
+//
+// Returns:
+// - false if the outbox is full or closed, the message is then dropped.
+func (o *Outbox) Post(msg Message) bool {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if o.messages == nil {
+		return false
+	}
+	select {
+	case o.messages <- msg:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops taking messages, the senders stop
+// once the queued messages are sent
+func (o *Outbox) Close() {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if o.messages != nil {
+		close(o.messages)
+		o.messages = nil
+	}
+}
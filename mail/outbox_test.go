@@ -0,0 +1,67 @@
+package mail
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutbox(t *testing.T) {
+	s := newSink(t)
+	mailer, err := New(Config{Host: "127.0.0.1", Port: s.port(), From: "noreply@example.com"})
+	assert.NoError(t, err)
+
+	failed := make(chan Message, 1)
+	outbox := NewOutbox(mailer, OUTBOX, SENDERS, func(msg Message, err error) {
+		failed <- msg
+	})
+	assert.True(t, outbox.Post(Message{To: []string{"ops@example.com"}, Text: "hi"}))
+	assert.True(t, outbox.Post(Message{To: []string{"bad"}, Subject: "Bad", Text: "hi"}))
+	select {
+	case <-s.messages:
+		assert.Equal(t, []string{"ops@example.com"}, <-s.rcpts)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+	select {
+	case msg := <-failed:
+		assert.Equal(t, "Bad", msg.Subject)
+	case <-time.After(5 * time.Second):
+		t.Fatal("failure not reported")
+	}
+
+	outbox.Close()
+	assert.False(t, outbox.Post(Message{To: []string{"ops@example.com"}, Text: "hi"}))
+}
+
+func TestOutboxFull(t *testing.T) {
+	// A server that never answers holds the only sender
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	mailer, err := New(Config{Host: "127.0.0.1", Port: port, From: "noreply@example.com"})
+	assert.NoError(t, err)
+	outbox := NewOutbox(mailer, 1, 1, func(Message, error) {})
+	defer outbox.Close()
+
+	msg := Message{To: []string{"ops@example.com"}, Text: "hi"}
+	assert.True(t, outbox.Post(msg))
+	select {
+	case conn := <-accepted:
+		defer conn.Close()
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not sent")
+	}
+	assert.True(t, outbox.Post(msg))
+	assert.False(t, outbox.Post(msg))
+}
@@ -2,27 +2,218 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"io"
+	"julien/bundle"
+	"julien/form"
+	"julien/fs"
 	"julien/julien"
+	"os"
+	"path"
 	"strconv"
+	"time"
 
 	"julien/web"
 )
 
-func start(j *julien.Julien, site *julien.Site, endpoint string) {
+func start(j *julien.Julien, site *julien.Site, endpoint string, dev bool, drafts bool, sitepath string, configpath string) {
 	jweb := web.New(j, site)
+	jweb.ShowDrafts(drafts)
+	if dev {
+		if err := jweb.Dev(sitepath, configpath); err != nil {
+			panic(err)
+		}
+	}
+	jweb.Start(endpoint)
+}
+
+// migrate copies form submissions from a data directory
+// into the storage configured for the data mount
+func migrate(args []string) {
+	cmd := flag.NewFlagSet("migrate", flag.ExitOnError)
+	configpath := cmd.String("config", "julien.yaml", "julien config file")
+	from := cmd.String("from", "data", "data directory to migrate from")
+	cmd.Parse(args)
+
+	j := julien.DefaultJulien()
+	julien.LoadConfig(*configpath, &j)
+
+	ddisk := web.MountStorage("data", j.Data)
+	fdisk := web.MountStorage("forms", j.Forms)
+	// Sqlite checkpoints its wal on close
+	for _, disk := range []fs.Storage{ddisk, fdisk} {
+		if closer, ok := disk.(io.Closer); ok {
+			defer closer.Close()
+		}
+	}
+	fdriver := web.MountDriver("forms", j.Forms.Driver)
+	ddriver := web.MountDriver("data", j.Data.Driver)
+	forms := form.Init(fdisk, ddisk, fdriver, ddriver)
+
+	src := fs.Mount(*from, j.Data.Index, j.Data.Ext)
+	count, err := forms.Migrate(src)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("migrated %d documents from %s to %s\n", count, *from, ddisk.Root())
+}
+
+// pack writes the site file, config, content, forms, template and
+// static mounts into a self contained executable or a zip archive
+func pack(args []string) {
+	cmd := flag.NewFlagSet("bundle", flag.ExitOnError)
+	sitepath := cmd.String("site", "index.md", "site markdown file")
+	configpath := cmd.String("config", "julien.yaml", "julien config file")
+	out := cmd.String("out", "site", "bundled executable or archive to write")
+	archive := cmd.Bool("archive", false, "write a zip archive instead of an executable")
+	exe := cmd.String("exe", "", "julien executable to bundle, defaults to this one")
+	cmd.Parse(args)
+
+	j := julien.DefaultJulien()
+	julien.LoadConfig(*configpath, &j)
+
+	if *archive {
+		file, err := os.Create(*out)
+		if err != nil {
+			panic(err)
+		}
+		defer file.Close()
+		if err := bundle.Write(file, j, *sitepath); err != nil {
+			panic(err)
+		}
+	} else {
+		if *exe == "" {
+			self, err := os.Executable()
+			if err != nil {
+				panic(err)
+			}
+			*exe = self
+		}
+		if err := bundle.Append(*exe, *out, j, *sitepath); err != nil {
+			panic(err)
+		}
+	}
+	fmt.Printf("bundled %s into %s\n", *sitepath, *out)
+}
+
+// build exports the site as static files
+func build(args []string) {
+	cmd := flag.NewFlagSet("build", flag.ExitOnError)
+	sitepath := cmd.String("site", "index.md", "site markdown file")
+	configpath := cmd.String("config", "julien.yaml", "julien config file")
+	out := cmd.String("out", "dist", "directory to export the site to")
+	formsurl := cmd.String("forms-url", "", "url of the julien instance serving the forms")
+	drafts := cmd.Bool("drafts", false, "build unpublished pages")
+	cmd.Parse(args)
+
+	j := julien.DefaultJulien()
+	site := julien.DefaultSite()
+	julien.LoadSite(*sitepath, &site)
+	julien.LoadConfig(*configpath, &j)
+
+	jweb := web.New(&j, &site)
+	jweb.Forms().SetAction(*formsurl)
+	jweb.ShowDrafts(*drafts)
+	count, err := jweb.Build(*out)
+	fmt.Printf("built %d pages into %s\n", count, *out)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// preview prints a link showing an unpublished page until it expires
+func preview(args []string) {
+	cmd := flag.NewFlagSet("preview", flag.ExitOnError)
+	sitepath := cmd.String("site", "index.md", "site markdown file")
+	configpath := cmd.String("config", "julien.yaml", "julien config file")
+	ttl := cmd.Duration("ttl", 24*time.Hour, "time the link stays valid")
+	cmd.Parse(args)
+	if cmd.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: julien preview [flags] <page path>")
+		os.Exit(2)
+	}
+
+	j := julien.DefaultJulien()
+	site := julien.DefaultSite()
+	julien.LoadSite(*sitepath, &site)
+	julien.LoadConfig(*configpath, &j)
+
+	ppath := path.Clean("/" + cmd.Arg(0))
+	token, err := web.PreviewToken(j.Preview.Secret, ppath, time.Now().Add(*ttl))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s?%s=%s\n", site.URL(ppath), web.PREVIEW_KEY, token)
+}
+
+// serve starts the site of a bundle
+func serve(b *bundle.Bundle, endpoint string, drafts bool) {
+	j, err := b.Config()
+	if err != nil {
+		panic(err)
+	}
+	site, err := b.Site()
+	if err != nil {
+		panic(err)
+	}
+	jweb := web.NewFS(&j, &site, b)
+	jweb.ShowDrafts(drafts)
 	jweb.Start(endpoint)
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		migrate(os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "bundle" {
+		pack(os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "build" {
+		build(os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "preview" {
+		preview(os.Args[2:])
+		return
+	}
+
 	sitepath := flag.String("site", "index.md", "site markdown file")
 	configpath := flag.String("config", "julien.yaml", "julien config file")
+	bundlepath := flag.String("bundle", "", "site bundle archive to serve")
 	port := flag.Int("port", 1234, "webserver port")
 	host := flag.String("host", "localhost", "webserver host")
+	dev := flag.Bool("dev", false, "reload the site and open pages on change")
+	drafts := flag.Bool("drafts", false, "show unpublished pages")
 	flag.Parse()
+	endpoint := (*host) + ":" + strconv.Itoa(*port)
+
+	if *bundlepath != "" {
+		b, err := bundle.Open(*bundlepath)
+		if err != nil {
+			panic(err)
+		}
+		serve(b, endpoint, *drafts)
+		return
+	}
+
+	// Serve the site bundled into this executable if any
+	if self, err := os.Executable(); err == nil && !*dev {
+		if b, err := bundle.Open(self); err == nil {
+			serve(b, endpoint, *drafts)
+			return
+		}
+	}
+
 	j := julien.DefaultJulien()
 	site := julien.DefaultSite()
 	julien.LoadSite(*sitepath, &site)
 	julien.LoadConfig(*configpath, &j)
-	endpoint := (*host) + ":" + strconv.Itoa(*port)
-	start(&j, &site, endpoint)
+	start(&j, &site, endpoint, *dev, *drafts, *sitepath, *configpath)
 }
@@ -0,0 +1,213 @@
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// MORE is the marker ending the summary of a page
+const MORE string = "<!--more-->"
+
+// SUMMARY is the number of words of a summary without marker
+const SUMMARY int = 70
+
+// WPM is the number of words read per minute
+const WPM int = 200
+
+// Document is a rendered markdown source and what was
+// computed from the same parse
+type Document struct {
+	HTML     string
+	Summary  string     // Html up to the more marker or the first words
+	Headings []*Heading // Top level headings and their sub headings
+	Words    int
+	Minutes  int // Reading time
+}
+
+// Heading is an entry of the table of contents of a Document
+type Heading struct {
+	Level    int
+	ID       string // Anchor of the heading, empty without anchors
+	Title    string
+	Children []*Heading
+}
+
+// Parse renders markdown and computes its table of contents, word
+// count, reading time and summary from the same syntax tree.
+//
+// Parameters:
+// - source: The markdown source.
+//
+// Returns:
+// - The Document.
+// - An error if the html cannot be written.
+func (md *Markdown) Parse(source string) (*Document, error) {
+	src := []byte(source)
+	root := md.engine.Parser().Parse(text.NewReader(src))
+
+	doc := &Document{Headings: make([]*Heading, 0)}
+	all := bytes.NewBuffer(nil)   // Text of every block
+	intro := bytes.NewBuffer(nil) // Text of the blocks but headings
+	stack := make([]*Heading, 0)
+
+	var marker ast.Node
+	err := ast.Walk(root, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			// Words of adjacent blocks are apart
+			if node.Type() == ast.TypeBlock {
+				all.WriteByte(' ')
+				intro.WriteByte(' ')
+			}
+			return ast.WalkContinue, nil
+		}
+		switch node := node.(type) {
+		case *ast.Heading:
+			title := strings.TrimSpace(plain(node, src))
+			heading := &Heading{Level: node.Level, Title: title, Children: make([]*Heading, 0)}
+			if id, ok := node.AttributeString("id"); ok {
+				if id, ok := id.([]byte); ok {
+					heading.ID = string(id)
+				}
+			}
+			all.WriteString(heading.Title + " ")
+			for len(stack) > 0 && stack[len(stack)-1].Level >= heading.Level {
+				stack = stack[:len(stack)-1]
+			}
+			if len(stack) == 0 {
+				doc.Headings = append(doc.Headings, heading)
+			} else {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, heading)
+			}
+			stack = append(stack, heading)
+			return ast.WalkSkipChildren, nil
+
+		case *ast.HTMLBlock:
+			if marker == nil && node.Parent() == root && isMore(node, src) {
+				marker = node
+			}
+
+		case *ast.Text, *ast.String:
+			text := plain(node, src)
+			all.WriteString(text)
+			intro.WriteString(text)
+		}
+		return ast.WalkContinue, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	doc.Words = len(strings.Fields(all.String()))
+	if wpm := md.wpm(); doc.Words > 0 {
+		doc.Minutes = max(1, (doc.Words+wpm/2)/wpm)
+	}
+
+	out := bytes.NewBuffer(nil)
+	if err := md.engine.Renderer().Render(out, src, root); err != nil {
+		return nil, err
+	}
+	doc.HTML = out.String()
+
+	if marker == nil {
+		limit := md.options.Summary
+		if limit < 1 {
+			limit = SUMMARY
+		}
+		words := strings.Fields(intro.String())
+		summary := strings.Join(words[:min(limit, len(words))], " ")
+		if len(words) > limit {
+			summary += " …"
+		}
+		if summary != "" {
+			doc.Summary = "<p>" + html.EscapeString(summary) + "</p>"
+		}
+		return doc, nil
+	}
+
+	// The summary is the rendering of the blocks before the marker
+	for node := marker; node != nil; {
+		next := node.NextSibling()
+		root.RemoveChild(root, node)
+		node = next
+	}
+	out.Reset()
+	if err := md.engine.Renderer().Render(out, src, root); err != nil {
+		return nil, err
+	}
+	doc.Summary = out.String()
+	return doc, nil
+}
+
+// wpm returns the words read per minute of the options
+func (md *Markdown) wpm() int {
+	if md.options.WPM < 1 {
+		return WPM
+	}
+	return md.options.WPM
+}
+
+// TOC renders the headings as nested lists of links to their anchors
+func (doc *Document) TOC() string {
+	if len(doc.Headings) == 0 {
+		return ""
+	}
+	out := bytes.NewBuffer(nil)
+	toc(out, doc.Headings)
+	return out.String()
+}
+
+func toc(out *bytes.Buffer, headings []*Heading) {
+	out.WriteString("<ul>")
+	for _, heading := range headings {
+		out.WriteString("<li>")
+		if heading.ID != "" {
+			out.WriteString(`<a href="#` + html.EscapeString(heading.ID) + `">` + html.EscapeString(heading.Title) + "</a>")
+		} else {
+			out.WriteString(html.EscapeString(heading.Title))
+		}
+		if len(heading.Children) > 0 {
+			toc(out, heading.Children)
+		}
+		out.WriteString("</li>")
+	}
+	out.WriteString("</ul>")
+}
+
+// isMore reports whether an html block is the more marker
+func isMore(node *ast.HTMLBlock, src []byte) bool {
+	lines := bytes.NewBuffer(nil)
+	for i := 0; i < node.Lines().Len(); i++ {
+		segment := node.Lines().At(i)
+		lines.Write(segment.Value(src))
+	}
+	return strings.ReplaceAll(strings.TrimSpace(lines.String()), " ", "") == MORE
+}
+
+// plain returns the text of a node and its inline children
+func plain(node ast.Node, src []byte) string {
+	out := bytes.NewBuffer(nil)
+	ast.Walk(node, func(child ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch child := child.(type) {
+		case *ast.Text:
+			out.Write(child.Segment.Value(src))
+			if child.SoftLineBreak() {
+				out.WriteByte(' ')
+			}
+		case *ast.String:
+			if child.IsCode() {
+				out.WriteString(html.UnescapeString(string(child.Value)))
+			} else {
+				out.Write(child.Value)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return out.String()
+}
@@ -0,0 +1,128 @@
+package markdown
+
+import (
+	"bytes"
+	"sync"
+
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+// Options are the markdown extensions of a site, set under
+// the markdown key of julien.yaml
+type Options struct {
+	GFM         bool   `yaml:"gfm"`         // Tables, strikethrough, autolinks and task lists
+	Footnotes   bool   `yaml:"footnotes"`   // [^1] references and their notes
+	Anchors     bool   `yaml:"anchors"`     // Ids of headings e.g <h2 id="getting-started">
+	Highlight   bool   `yaml:"highlight"`   // Syntax highlighting of fenced code blocks
+	Style       string `yaml:"style"`       // Highlighting style, defaults to github
+	Typographer bool   `yaml:"typographer"` // Smart quotes, dashes and ellipses
+	Unsafe      bool   `yaml:"unsafe"`      // Keep the raw html of the markdown
+	HardWraps   bool   `yaml:"hardwraps"`   // Line breaks as <br>
+	Summary     int    `yaml:"summary"`     // Words of summaries without a more marker
+	WPM         int    `yaml:"wpm"`         // Words read per minute
+}
+
+// Markdown renders markdown to html with the extensions of its options,
+// it is safe for concurrent use
+type Markdown struct {
+	options Options
+	engine  goldmark.Markdown
+}
+
+// Default returns the options of a site without a markdown config,
+// every extension but hard wraps is enabled and raw html is kept
+func Default() Options {
+	return Options{
+		GFM:         true,
+		Footnotes:   true,
+		Anchors:     true,
+		Highlight:   true,
+		Style:       "github",
+		Typographer: true,
+		Unsafe:      true,
+		Summary:     SUMMARY,
+		WPM:         WPM,
+	}
+}
+
+// New creates a Markdown renderer.
+//
+// Parameters:
+// - options: The extensions to render with.
+//
+// Returns:
+// - The Markdown renderer.
+func New(options Options) *Markdown {
+	extensions := make([]goldmark.Extender, 0)
+	if options.GFM {
+		extensions = append(extensions, extension.GFM)
+	}
+	if options.Footnotes {
+		extensions = append(extensions, extension.Footnote)
+	}
+	if options.Typographer {
+		extensions = append(extensions, extension.Typographer)
+	}
+	if options.Highlight {
+		style := options.Style
+		if style == "" {
+			style = "github"
+		}
+		extensions = append(extensions, highlighting.NewHighlighting(highlighting.WithStyle(style)))
+	}
+
+	parsers := make([]parser.Option, 0)
+	if options.Anchors {
+		parsers = append(parsers, parser.WithAutoHeadingID())
+	}
+	rendering := make([]renderer.Option, 0)
+	if options.Unsafe {
+		rendering = append(rendering, html.WithUnsafe())
+	}
+	if options.HardWraps {
+		rendering = append(rendering, html.WithHardWraps())
+	}
+
+	engine := goldmark.New(
+		goldmark.WithExtensions(extensions...),
+		goldmark.WithParserOptions(parsers...),
+		goldmark.WithRendererOptions(rendering...),
+	)
+	return &Markdown{options: options, engine: engine}
+}
+
+var fallback = sync.OnceValue(func() *Markdown {
+	return New(Default())
+})
+
+// Fallback returns a shared renderer of the default options
+func Fallback() *Markdown {
+	return fallback()
+}
+
+// Options returns the options of the renderer
+func (md *Markdown) Options() Options {
+	return md.options
+}
+
+// Render converts markdown to html.
+//
+// Parameters:
+// - source: The markdown source.
+//
+// Returns:
+// - The html.
+// - An error if the html cannot be written.
+func (md *Markdown) Render(source string) (string, error) {
+	out := bytes.NewBuffer(nil)
+	if err := md.engine.Convert([]byte(source), out); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,74 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const SOURCE = `## Getting started
+
+| Name | Value |
+| ---- | ----- |
+| a    | 1     |
+
+Julien[^1] says "hello" -- ~~bye~~ <span>raw</span>
+
+[^1]: King of the lemurs.
+
+` + "```go\nfunc main() {}\n```\n"
+
+func TestRender(t *testing.T) {
+	html, err := New(Default()).Render(SOURCE)
+	assert.NoError(t, err)
+	assert.Contains(t, html, `<h2 id="getting-started">Getting started</h2>`)
+	assert.Contains(t, html, "<table>")
+	assert.Contains(t, html, `<a href="#fn:1"`)
+	assert.Contains(t, html, "&ldquo;hello&rdquo; &ndash;")
+	assert.Contains(t, html, "<del>bye</del>")
+	assert.Contains(t, html, "<span>raw</span>")
+	assert.Contains(t, html, `<span style="color:#000;font-weight:bold">func</span>`)
+}
+
+func TestRenderOptions(t *testing.T) {
+	html, err := New(Options{}).Render(SOURCE)
+	assert.NoError(t, err)
+	assert.Contains(t, html, "<h2>Getting started</h2>")
+	assert.NotContains(t, html, "<table>")
+	assert.NotContains(t, html, "<del>")
+	assert.Contains(t, html, "&quot;hello&quot;")
+	assert.Contains(t, html, "<!-- raw HTML omitted -->")
+	assert.Contains(t, html, `<pre><code class="language-go">`)
+}
+
+func TestParse(t *testing.T) {
+	source := "# King \"Julien\"\n\nAll hail the king of the lemurs.\n\n## Reign\n\nHe rules.\n\n### Parties\n\n## Music\n"
+	doc, err := New(Default()).Parse(source)
+	assert.NoError(t, err)
+	assert.Equal(t, 14, doc.Words)
+	assert.Equal(t, 1, doc.Minutes)
+	assert.Contains(t, doc.HTML, `<h2 id="reign">Reign</h2>`)
+
+	assert.Len(t, doc.Headings, 1)
+	king := doc.Headings[0]
+	assert.Equal(t, "King “Julien”", king.Title)
+	assert.Equal(t, "king-julien", king.ID)
+	assert.Len(t, king.Children, 2)
+	assert.Equal(t, "parties", king.Children[0].Children[0].ID)
+	assert.Equal(t, `<ul><li><a href="#king-julien">King “Julien”</a><ul><li><a href="#reign">Reign</a><ul><li><a href="#parties">Parties</a></li></ul></li><li><a href="#music">Music</a></li></ul></li></ul>`, doc.TOC())
+	assert.Equal(t, "<p>All hail the king of the lemurs. He rules.</p>", doc.Summary)
+}
+
+func TestParseSummary(t *testing.T) {
+	md := New(Options{Summary: 3, WPM: 2})
+	doc, err := md.Parse("One two a<b four five\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "<p>One two a&lt;b …</p>", doc.Summary)
+	assert.Equal(t, 3, doc.Minutes)
+
+	doc, err = md.Parse("Intro *text*\n\n<!-- more -->\n\nRest of the page\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "<p>Intro <em>text</em></p>\n", doc.Summary)
+	assert.Contains(t, doc.HTML, "Rest of the page")
+	assert.Equal(t, 6, doc.Words)
+}
@@ -47,7 +47,11 @@ func (c *Collection) Get(index int) *Page {
 	return nil
 }
 
+// Slice returns the entries from start to end, bounds
+// out of range are clamped to the collection
 func (c *Collection) Slice(start, end int) *Collection {
+	end = min(max(end, 0), c.Count())
+	start = min(max(start, 0), end)
 	return NewPageCollection(c.Entries()[start:end])
 }
 
@@ -111,14 +115,19 @@ func (c *Collection) Search(token string) *Collection {
 	return NewPageCollection(entries)
 }
 
+// SortBy sorts the entries by a value of the given key,
+// entries without the value are kept last in both orders
 func (c *Collection) SortBy(key string, order ...string) *Collection {
 	entries := make([]*Page, c.Count())
 	copy(entries, c.Entries())
 	sort.SliceStable(entries, func(i, j int) bool {
 		aval := entries[i]
 		bval := entries[j]
-		avalue := aval.Get(key)
-		bvalue := bval.Get(key)
+		avalue := aval.Value(key)
+		bvalue := bval.Value(key)
+		if avalue == nil || bvalue == nil {
+			return avalue != nil && bvalue == nil
+		}
 
 		atype := reflect.TypeOf(avalue).String()
 		btype := reflect.TypeOf(bvalue).String()
@@ -166,3 +175,23 @@ func (c *Collection) SortBy(key string, order ...string) *Collection {
 	})
 	return NewPageCollection(entries)
 }
+
+// SortByDate sorts the entries by their date frontmatter,
+// entries without a date are kept last in both orders
+func (c *Collection) SortByDate(order ...string) *Collection {
+	entries := make([]*Page, c.Count())
+	copy(entries, c.Entries())
+	desc := len(order) > 0 && order[0] == "desc"
+	sort.SliceStable(entries, func(i, j int) bool {
+		adate := entries[i].Date()
+		bdate := entries[j].Date()
+		if adate.IsZero() || bdate.IsZero() {
+			return !adate.IsZero() && bdate.IsZero()
+		}
+		if desc {
+			return adate.After(bdate)
+		}
+		return adate.Before(bdate)
+	})
+	return NewPageCollection(entries)
+}
@@ -0,0 +1,65 @@
+package pager
+
+import (
+	"strings"
+	"time"
+)
+
+// DATE_LAYOUTS are the layouts of the frontmatter dates pages are parsed with
+var DATE_LAYOUTS = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ParseTime parses a frontmatter date value.
+//
+// Parameters:
+// - value: A time or a string in one of DATE_LAYOUTS, dates without
+// a zone are in UTC.
+//
+// Returns:
+// - The time and true, or the zero time and false if value is not a date.
+func ParseTime(value interface{}) (time.Time, bool) {
+	switch value := value.(type) {
+	case time.Time:
+		return value, true
+	case string:
+		value = strings.TrimSpace(value)
+		for _, layout := range DATE_LAYOUTS {
+			if parsed, err := time.Parse(layout, value); err == nil {
+				return parsed, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// Time returns the date of a frontmatter key or the zero time
+func (page *Page) Time(key string) time.Time {
+	value, _ := ParseTime(page.Get(key))
+	return value
+}
+
+// Date returns the date frontmatter of the page or the zero time
+func (page *Page) Date() time.Time {
+	return page.Time("date")
+}
+
+// Updated returns the updated frontmatter of the page or its date
+func (page *Page) Updated() time.Time {
+	if updated := page.Time("updated"); !updated.IsZero() {
+		return updated
+	}
+	return page.Date()
+}
+
+// Timestamp returns the modification time of the page file
+func (page *Page) Timestamp() time.Time {
+	if page.entry == nil {
+		return time.Time{}
+	}
+	return page.entry.Timestamp()
+}
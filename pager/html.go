@@ -0,0 +1,98 @@
+package pager
+
+import (
+	htmltemplate "html/template"
+	"julien/markdown"
+)
+
+// UseMarkdown sets the renderer of the body of pages
+func (root *Root) UseMarkdown(md *markdown.Markdown) {
+	root.markdown = md
+}
+
+// Markdown returns the renderer of the body of pages
+func (root *Root) Markdown() *markdown.Markdown {
+	if root.markdown == nil {
+		return markdown.Fallback()
+	}
+	return root.markdown
+}
+
+// markdowner returns the renderer of the root of the page
+func (page *Page) markdowner() *markdown.Markdown {
+	if page.root == nil {
+		return markdown.Fallback()
+	}
+	return page.root.Markdown()
+}
+
+// Document returns the body of the page rendered from markdown with
+// its headings, word count and summary. It is rendered once per page
+// and kept until the body changes.
+//
+// Returns:
+// - The Document, empty if the body cannot be rendered.
+func (page *Page) Document() *markdown.Document {
+	if doc := page.document.Load(); doc != nil {
+		return doc
+	}
+	doc, err := page.markdowner().Parse(page.body)
+	if err != nil {
+		return &markdown.Document{Headings: make([]*markdown.Heading, 0)}
+	}
+	page.document.Store(doc)
+	return doc
+}
+
+// HTML returns the html of the body of the page, templates
+// escaping strings should mark it safe e.g {{ Page.HTML()|safe }}
+func (page *Page) HTML() htmltemplate.HTML {
+	return htmltemplate.HTML(page.Document().HTML)
+}
+
+// TOC returns the headings of the page as a tree
+func (page *Page) TOC() []*markdown.Heading {
+	return page.Document().Headings
+}
+
+// TableOfContents returns the headings of the page as nested lists of links
+func (page *Page) TableOfContents() htmltemplate.HTML {
+	return htmltemplate.HTML(page.Document().TOC())
+}
+
+// WordCount returns the number of words of the body
+func (page *Page) WordCount() int {
+	return page.Document().Words
+}
+
+// ReadingTime returns the minutes it takes to read the body
+func (page *Page) ReadingTime() int {
+	return page.Document().Minutes
+}
+
+// Summary returns the summary frontmatter rendered from markdown, or
+// the body up to a <!--more--> line, or the first words of the body
+func (page *Page) Summary() htmltemplate.HTML {
+	if summary := page.GetString("summary"); summary != "" {
+		html, err := page.markdowner().Render(summary)
+		if err == nil {
+			return htmltemplate.HTML(html)
+		}
+	}
+	return htmltemplate.HTML(page.Document().Summary)
+}
+
+// Value returns a frontmatter value or a value computed from the
+// body for the keys words and reading_time, e.g for sorting
+func (page *Page) Value(key string) interface{} {
+	if page.Has(key) {
+		return page.Get(key)
+	}
+	switch key {
+	case "words":
+		return page.WordCount()
+	case "reading_time":
+		return page.ReadingTime()
+	}
+	return nil
+}
@@ -2,9 +2,14 @@ package pager
 
 import (
 	"julien/contract"
+	"julien/driver"
 	"julien/fs"
+	"julien/markdown"
+	jutils "julien/utils"
 	"path"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 var EMPTY_PAGES = make([]*Page, 0)
@@ -14,19 +19,59 @@ type Page struct {
 	body     string
 	entry    *fs.Entry
 	root     *Root
+	driver   contract.Driver
 	extended map[interface{}]interface{}
+	specs    *sync.Map                          // Values resolved from the page and parent specs
+	document *atomic.Pointer[markdown.Document] // Rendered body
 }
 
 type Root struct {
-	disk   *fs.Disk
+	disk   fs.Storage
 	driver contract.Driver
+	pages  *jutils.Cache[*Page]   // Parsed pages by path once indexed
+	lists  *jutils.Cache[[]*Page] // Directory entries by path once indexed
+
+	taxonomies *jutils.Cache[*Taxonomy] // Taxonomies by name and key once indexed
+
+	drafts   bool               // List unpublished pages
+	markdown *markdown.Markdown // Renders the body of pages
 }
 
-func Init(disk *fs.Disk, driver contract.Driver) Root {
+func Init(disk fs.Storage, driver contract.Driver) Root {
 	return Root{
 		disk:   disk,
 		driver: driver,
+		pages:  jutils.NewCache[*Page](),
+		lists:  jutils.NewCache[[]*Page](),
+
+		taxonomies: jutils.NewCache[*Taxonomy](),
+	}
+}
+
+// Index parses every page into memory, Find, List and collections are
+// then served from memory until Invalidate is called on changes.
+//
+// Returns:
+// - An error if a page cannot be parsed, pages parsed so far stay indexed.
+func (root *Root) Index() error {
+	root.pages.Enable()
+	root.lists.Enable()
+	root.taxonomies.Enable()
+	home, err := root.Find("/")
+	if err != nil {
+		return err
 	}
+	return root.walk(home, root.entries, func(page *Page) error {
+		return nil
+	})
+}
+
+// Invalidate drops the parsed pages after a change of the content,
+// they are parsed again on their next use.
+func (root *Root) Invalidate() {
+	root.pages.Clear()
+	root.lists.Clear()
+	root.taxonomies.Clear()
 }
 
 func (root *Root) create_entry_page(entry *fs.Entry) (*Page, error) {
@@ -35,7 +80,8 @@ func (root *Root) create_entry_page(entry *fs.Entry) (*Page, error) {
 		return nil, err
 	}
 
-	frontmatter, body, err := root.driver.Parse(raw)
+	pdriver := driver.Resolve(root.driver, raw)
+	frontmatter, body, err := pdriver.Parse(raw)
 	if err != nil {
 		return nil, err
 	}
@@ -45,7 +91,10 @@ func (root *Root) create_entry_page(entry *fs.Entry) (*Page, error) {
 		body:     body,
 		entry:    entry,
 		root:     root,
+		driver:   pdriver,
 		extended: make(map[interface{}]interface{}),
+		specs:    &sync.Map{},
+		document: &atomic.Pointer[markdown.Document]{},
 	}
 
 	if page.IsFile() && !page.IsRootIndex() {
@@ -63,7 +112,25 @@ func (root *Root) create_entry_page(entry *fs.Entry) (*Page, error) {
 	return &page, nil
 }
 
+// List returns the published pages of a directory, unpublished
+// pages are listed again once published without a restart
 func (root *Root) List(ppath string) ([]*Page, error) {
+	pages, err := root.entries(ppath)
+	if err != nil {
+		return nil, err
+	}
+	return root.visible(pages), nil
+}
+
+// entries returns every page of a directory, published or not
+func (root *Root) entries(ppath string) ([]*Page, error) {
+	key := root.key(ppath)
+	if pages, ok := root.lists.Get(key); ok {
+		return copies(pages), nil
+	}
+	generation := root.lists.Generation()
+	pgeneration := root.pages.Generation()
+
 	pages := make([]*Page, 0)
 	entries, err := root.disk.List(ppath)
 	if err != nil {
@@ -76,6 +143,9 @@ func (root *Root) List(ppath string) ([]*Page, error) {
 
 			if entry.IsFile() {
 				page, err = root.create_entry_page(entry)
+				if err == nil {
+					root.pages.Set(pgeneration, root.key(entry.Path()), page)
+				}
 			} else if entry.IsDir() {
 				page, err = root.Find(entry.Path())
 			}
@@ -94,15 +164,91 @@ func (root *Root) List(ppath string) ([]*Page, error) {
 			pages = append(pages, page)
 		}
 	}
-	return pages, nil
+	root.lists.Set(generation, key, pages)
+	return copies(pages), nil
+}
+
+// key returns the cache key of a page path with or without extension
+func (root *Root) key(ppath string) string {
+	return strings.TrimSuffix(path.Clean(ppath), "."+root.disk.Ext())
 }
 
 func (root *Root) Find(ppath string) (*Page, error) {
+	key := root.key(ppath)
+	if page, ok := root.pages.Get(key); ok {
+		return page.copy(), nil
+	}
+	generation := root.pages.Generation()
+
 	entry, err := root.disk.Find(path.Clean(ppath))
 	if err != nil {
 		return nil, err
 	}
-	return root.create_entry_page(entry)
+	page, err := root.create_entry_page(entry)
+	if err != nil {
+		return nil, err
+	}
+	root.pages.Set(generation, key, page)
+	return page.copy(), nil
+}
+
+// copy returns a page that can be changed without changing the page
+// kept in memory, which other requests share. The resolved specs and
+// rendered body are shared until the copy changes.
+func (page *Page) copy() *Page {
+	meta := make(map[string]interface{}, len(page.meta))
+	for key, value := range page.meta {
+		meta[key] = value
+	}
+	copied := *page
+	copied.meta = meta
+	return &copied
+}
+
+// copies returns a copy of each page of a list kept in memory
+func copies(pages []*Page) []*Page {
+	copied := make([]*Page, 0, len(pages))
+	for _, page := range pages {
+		copied = append(copied, page.copy())
+	}
+	return copied
+}
+
+// Walk calls fn for the home page and every published page below it,
+// directory pages before their entries. Index files are not visited,
+// their directory page is.
+//
+// Parameters:
+// - fn: The function called for each page, an error stops the walk.
+//
+// Returns:
+// - The first error returned by fn or met listing a directory.
+func (root *Root) Walk(fn func(*Page) error) error {
+	home, err := root.Find("/")
+	if err != nil {
+		return err
+	}
+	return root.walk(home, root.List, fn)
+}
+
+// walk visits a page and the pages below it listed by list
+func (root *Root) walk(page *Page, list func(string) ([]*Page, error), fn func(*Page) error) error {
+	if err := fn(page); err != nil {
+		return err
+	}
+	if !page.IsDir() {
+		return nil
+	}
+	pages, err := list(page.EPath())
+	if err != nil {
+		return err
+	}
+	for _, child := range pages {
+		if err := root.walk(child, list, fn); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (root *Root) Open(ppath string) *Page {
@@ -118,6 +264,7 @@ func (root *Root) Dump(ppath string, content []byte) (*Page, error) {
 	if err != nil {
 		return nil, err
 	}
+	root.Invalidate()
 	return root.Find(ppath)
 }
 
@@ -128,6 +275,7 @@ func (page *Page) Name() string {
 func (page *Page) Body(body ...string) string {
 	if len(body) > 0 {
 		page.body = body[0]
+		page.document = &atomic.Pointer[markdown.Document]{}
 	}
 	return page.body
 }
@@ -166,6 +314,9 @@ func (page *Page) IsIndex() bool {
 func (page *Page) Path() string {
 	epath := page.EPath()
 	if page.entry.IsDir() {
+		if epath == "." {
+			return ""
+		}
 		return epath
 	}
 
@@ -180,7 +331,7 @@ func (page *Page) Path() string {
 		}
 		return ppath
 	}
-	return strings.TrimRight(epath, "."+page.Ext())
+	return strings.TrimSuffix(epath, "."+page.Ext())
 }
 
 func (page *Page) APath() string {
@@ -253,16 +404,18 @@ func (page *Page) Has(key string) bool {
 
 func (page *Page) Set(key string, value interface{}) {
 	page.meta[key] = value
+	page.specs = &sync.Map{}
 }
 
 func (page *Page) Fill(frontmatter map[string]interface{}) {
 	for key, value := range frontmatter {
 		page.meta[key] = value
 	}
+	page.specs = &sync.Map{}
 }
 
 func (page *Page) Dump() ([]byte, error) {
-	bytes, err := page.root.driver.Dump(&page.meta, page.body)
+	bytes, err := page.driver.Dump(&page.meta, page.body)
 	if err != nil {
 		return nil, err
 	}
@@ -274,7 +427,11 @@ func (page *Page) Save() error {
 	if err != nil {
 		return err
 	}
-	return page.entry.Write(bytes)
+	if err := page.entry.Write(bytes); err != nil {
+		return err
+	}
+	page.root.Invalidate()
+	return nil
 }
 
 func (page *Page) Metadata() map[string]interface{} {
@@ -300,6 +457,16 @@ func (page *Page) IsRootIndex() bool {
 }
 
 func (page *Page) GetStringValueSpecOrNameRecusive(key string) string {
+	// Parent pages are looked up once per page
+	if value, ok := page.specs.Load(key); ok {
+		return value.(string)
+	}
+	value := page.resolve(key)
+	page.specs.Store(key, value)
+	return value
+}
+
+func (page *Page) resolve(key string) string {
 	value, ok := page.Get(key).(string)
 	if ok {
 		return value
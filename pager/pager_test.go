@@ -0,0 +1,193 @@
+package pager
+
+import (
+	"julien/driver"
+	"julien/fs"
+	"julien/markdown"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRootFind(t *testing.T) {
+	disk := fs.NewMemory("index", "md")
+	disk.Dump("index", []byte("---\ntitle: Home\n---\nWelcome"))
+	disk.Dump("articles/index", []byte("---\ntitle: Articles\npage:\n    view: article\n---\n"))
+	disk.Dump("articles/one", []byte("---\ntitle: One\n---\nFirst"))
+
+	root := Init(disk, &driver.Yaml{})
+
+	page, err := root.Find("articles/one")
+	assert.NoError(t, err)
+	assert.Equal(t, "One", page.Get("title"))
+	assert.Equal(t, "First", page.Body())
+	assert.Equal(t, "/articles/one", page.APath())
+
+	// View is inherited from the parent index page spec
+	assert.Equal(t, "article", page.View())
+
+	pages, err := root.List("articles")
+	assert.NoError(t, err)
+	assert.Len(t, pages, 1)
+
+	home, err := root.Find("/")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, home.Collection().Count())
+}
+
+func TestRootWalk(t *testing.T) {
+	disk := fs.NewMemory("index", "md")
+	disk.Dump("index", []byte("---\ntitle: Home\n---\n"))
+	disk.Dump("about", []byte("---\ntitle: About\n---\n"))
+	disk.Dump("articles/index", []byte("---\ntitle: Articles\n---\n"))
+	disk.Dump("articles/random", []byte("---\ntitle: Random\n---\n"))
+	disk.Dump("articles/nested/index", []byte("---\ntitle: Nested\n---\n"))
+	disk.Dump("articles/nested/deep", []byte("---\ntitle: Deep\n---\n"))
+	disk.Dump("drafts/one", []byte("---\ntitle: No index\n---\n"))
+
+	root := Init(disk, &driver.Yaml{})
+
+	paths := make([]string, 0)
+	err := root.Walk(func(page *Page) error {
+		paths = append(paths, page.APath())
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"/",
+		"/about",
+		"/articles",
+		"/articles/nested",
+		"/articles/nested/deep",
+		"/articles/random",
+	}, paths)
+}
+
+func TestRootIndex(t *testing.T) {
+	disk := fs.NewMemory("index", "md")
+	disk.Dump("index", []byte("---\ntitle: Home\n---\n"))
+	disk.Dump("articles/index", []byte("---\ntitle: Articles\npage:\n    view: article\n---\n"))
+	disk.Dump("articles/one", []byte("---\ntitle: One\n---\n"))
+
+	root := Init(disk, &driver.Yaml{})
+	assert.NoError(t, root.Index())
+
+	// Indexed pages are served from memory
+	disk.Dump("articles/one", []byte("---\ntitle: Changed\n---\n"))
+	disk.Dump("articles/two", []byte("---\ntitle: Two\n---\n"))
+	disk.Dump("articles/index", []byte("---\ntitle: Articles\npage:\n    view: post\n---\n"))
+
+	page, err := root.Find("articles/one")
+	assert.NoError(t, err)
+	assert.Equal(t, "One", page.Get("title"))
+	assert.Equal(t, "article", page.View())
+	pages, err := root.List("articles")
+	assert.NoError(t, err)
+	assert.Len(t, pages, 1)
+
+	root.Invalidate()
+
+	page, err = root.Find("articles/one")
+	assert.NoError(t, err)
+	assert.Equal(t, "Changed", page.Get("title"))
+	assert.Equal(t, "post", page.View())
+	pages, err = root.List("articles")
+	assert.NoError(t, err)
+	assert.Len(t, pages, 2)
+}
+
+func TestRootIndexCopies(t *testing.T) {
+	disk := fs.NewMemory("index", "md")
+	disk.Dump("index", []byte("---\ntitle: Home\n---\n"))
+	disk.Dump("one", []byte("---\ntitle: One\n---\nBody\n"))
+
+	root := Init(disk, &driver.Yaml{})
+	assert.NoError(t, root.Index())
+
+	// Changes to a page stay with the request that made them
+	page, err := root.Find("one")
+	assert.NoError(t, err)
+	assert.Equal(t, "One", page.GetStringValueSpecOrNameRecusive("title"))
+	page.Set("title", "Changed")
+	page.Body("Changed")
+	assert.Equal(t, "Changed", page.GetStringValueSpecOrNameRecusive("title"))
+
+	page, err = root.Find("one")
+	assert.NoError(t, err)
+	assert.Equal(t, "One", page.GetStringValueSpecOrNameRecusive("title"))
+	assert.Equal(t, "<p>Body</p>\n", string(page.HTML()))
+
+	pages, err := root.List("/")
+	assert.NoError(t, err)
+	pages[0].Fill(map[string]interface{}{"title": "Filled"})
+	pages, err = root.List("/")
+	assert.NoError(t, err)
+	assert.Equal(t, "One", pages[0].Get("title"))
+}
+
+func TestPageHTML(t *testing.T) {
+	disk := fs.NewMemory("index", "md")
+	disk.Dump("index", []byte("---\ntitle: Home\n---\n## Hello\n\n<b>raw</b>\n"))
+
+	root := Init(disk, &driver.Yaml{})
+	page, err := root.Find("/")
+	assert.NoError(t, err)
+	assert.Equal(t, "<h2 id=\"hello\">Hello</h2>\n<p><b>raw</b></p>\n", string(page.HTML()))
+
+	root.UseMarkdown(markdown.New(markdown.Options{}))
+	page.Body("## Bye")
+	assert.Equal(t, "<h2>Bye</h2>\n", string(page.HTML()))
+}
+
+func TestPageDocument(t *testing.T) {
+	disk := fs.NewMemory("index", "md")
+	disk.Dump("index", []byte("---\ntitle: Home\n---\n"))
+	disk.Dump("short", []byte("---\ntitle: Short\n---\n## One\n\nTwo words\n"))
+	disk.Dump("long", []byte("---\ntitle: Long\n---\nA longer intro\n\n<!--more-->\n\n## Body\n\nwith more words\n"))
+	disk.Dump("set", []byte("---\ntitle: Set\nsummary: Set *here*\nwords: 100\n---\nText\n"))
+
+	root := Init(disk, &driver.Yaml{})
+	long, err := root.Find("long")
+	assert.NoError(t, err)
+	assert.Equal(t, 7, long.WordCount())
+	assert.Equal(t, 1, long.ReadingTime())
+	assert.Equal(t, "<p>A longer intro</p>\n", string(long.Summary()))
+	assert.Equal(t, "body", long.TOC()[0].ID)
+	assert.Contains(t, string(long.TableOfContents()), `<a href="#body">Body</a>`)
+
+	set, _ := root.Find("set")
+	assert.Equal(t, "<p>Set <em>here</em></p>\n", string(set.Summary()))
+
+	// Frontmatter values take precedence over computed ones
+	pages, err := root.List("/")
+	assert.NoError(t, err)
+	sorted := NewPageCollection(pages).SortBy("words", "desc")
+	assert.Equal(t, "Set", sorted.Get(0).Get("title"))
+	assert.Equal(t, "Long", sorted.Get(1).Get("title"))
+	assert.Equal(t, "Short", sorted.Get(2).Get("title"))
+}
+
+func TestCollectionSortByMissing(t *testing.T) {
+	disk := fs.NewMemory("index", "md")
+	disk.Dump("index", []byte("---\ntitle: Home\n---\n"))
+	disk.Dump("a", []byte("---\ntitle: A\nrank: 2\n---\n"))
+	disk.Dump("b", []byte("---\ntitle: B\n---\n"))
+	disk.Dump("c", []byte("---\ntitle: C\nrank: 1\n---\n"))
+	disk.Dump("d", []byte("---\ntitle: D\nrank: 3\n---\n"))
+
+	root := Init(disk, &driver.Yaml{})
+	pages, err := root.List("/")
+	assert.NoError(t, err)
+
+	titles := func(c *Collection) []string {
+		names := make([]string, 0)
+		for _, page := range c.Entries() {
+			names = append(names, page.GetString("title"))
+		}
+		return names
+	}
+	// Pages without the key are kept last in both orders
+	collection := NewPageCollection(pages)
+	assert.Equal(t, []string{"C", "A", "D", "B"}, titles(collection.SortBy("rank")))
+	assert.Equal(t, []string{"D", "A", "C", "B"}, titles(collection.SortBy("rank", "desc")))
+}
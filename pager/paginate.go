@@ -0,0 +1,163 @@
+package pager
+
+import (
+	"path"
+	"strconv"
+	"strings"
+)
+
+// PAGE_PATH is the path element before the number of a paginated page
+const PAGE_PATH string = "page"
+
+type Paginator struct {
+	collection *Collection
+	size       int
+	current    int
+	base       string
+}
+
+// Link is a numbered page of a Paginator
+type Link struct {
+	Number  int
+	URL     string
+	Current bool
+}
+
+// Paginate splits the collection into pages of size entries. The
+// current page number is clamped to the pages of the collection.
+//
+// Parameters:
+// - size: The number of entries per page, at least 1.
+// - current: The page number being rendered, starting at 1.
+// - base: The url of the first page, other pages are below base/page/N.
+//
+// Returns:
+// - The Paginator of the collection.
+func (c *Collection) Paginate(size int, current int, base string) *Paginator {
+	if size < 1 {
+		size = 1
+	}
+	p := &Paginator{
+		collection: c,
+		size:       size,
+		base:       "/" + strings.Trim(base, "/"),
+	}
+	p.current = min(max(current, 1), p.Total())
+	return p
+}
+
+// SplitPageNumber splits the path of a paginated page like
+// articles/page/2 into the page path and the page number.
+//
+// Parameters:
+// - ppath: The requested path.
+//
+// Returns:
+// - The path of the paginated page, "" for the home page.
+// - The page number.
+// - false if ppath is not the path of a paginated page.
+func SplitPageNumber(ppath string) (string, int, bool) {
+	ppath = strings.Trim(ppath, "/")
+	dir, num := path.Split(ppath)
+	number, err := strconv.Atoi(num)
+	if err != nil || number < 1 {
+		return "", 0, false
+	}
+	dir = strings.TrimSuffix(dir, "/")
+	if path.Base(dir) != PAGE_PATH {
+		return "", 0, false
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(dir, PAGE_PATH), "/"), number, true
+}
+
+// Items returns the entries of the current page
+func (p *Paginator) Items() *Collection {
+	start := (p.current - 1) * p.size
+	return p.collection.Slice(start, start+p.size)
+}
+
+func (p *Paginator) Current() int {
+	return p.current
+}
+
+// Total returns the number of pages, an empty collection has one
+func (p *Paginator) Total() int {
+	return max((p.collection.Count()+p.size-1)/p.size, 1)
+}
+
+func (p *Paginator) Size() int {
+	return p.size
+}
+
+func (p *Paginator) HasPrev() bool {
+	return p.current > 1
+}
+
+func (p *Paginator) HasNext() bool {
+	return p.current < p.Total()
+}
+
+// Prev returns the previous page number or 0 on the first page
+func (p *Paginator) Prev() int {
+	if !p.HasPrev() {
+		return 0
+	}
+	return p.current - 1
+}
+
+// Next returns the next page number or 0 on the last page
+func (p *Paginator) Next() int {
+	if !p.HasNext() {
+		return 0
+	}
+	return p.current + 1
+}
+
+// PrevURL returns the previous page url or "" on the first page
+func (p *Paginator) PrevURL() string {
+	if !p.HasPrev() {
+		return ""
+	}
+	return p.URL(p.Prev())
+}
+
+// NextURL returns the next page url or "" on the last page
+func (p *Paginator) NextURL() string {
+	if !p.HasNext() {
+		return ""
+	}
+	return p.URL(p.Next())
+}
+
+// URL returns the url of a page number, the first page is the base url
+func (p *Paginator) URL(number int) string {
+	if number <= 1 {
+		return p.base
+	}
+	return path.Join(p.base, PAGE_PATH, strconv.Itoa(number))
+}
+
+// Links returns the links of the pages around the current page.
+//
+// Parameters:
+// - window: The number of links, the current page is centered when odd.
+//
+// Returns:
+// - The links in page order, shifted near the first and last pages.
+func (p *Paginator) Links(window int) []Link {
+	total := p.Total()
+	window = max(min(window, total), 1)
+	start := max(p.current-window/2, 1)
+	end := min(start+window-1, total)
+	start = max(end-window+1, 1)
+
+	links := make([]Link, 0, window)
+	for number := start; number <= end; number++ {
+		links = append(links, Link{
+			Number:  number,
+			URL:     p.URL(number),
+			Current: number == p.current,
+		})
+	}
+	return links
+}
@@ -0,0 +1,86 @@
+package pager
+
+import (
+	"fmt"
+	"julien/driver"
+	"julien/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func articles(count int) *Collection {
+	disk := fs.NewMemory("index", "md")
+	disk.Dump("articles/index", []byte("---\ntitle: Articles\n---\n"))
+	for i := 1; i <= count; i++ {
+		disk.Dump(fmt.Sprintf("articles/%02d", i), []byte(fmt.Sprintf("---\ntitle: Article %d\n---\n", i)))
+	}
+	root := Init(disk, &driver.Yaml{})
+	return root.Open("articles").Collection()
+}
+
+func TestPaginate(t *testing.T) {
+	p := articles(7).Paginate(3, 2, "/articles")
+	assert.Equal(t, 3, p.Total())
+	assert.Equal(t, 2, p.Current())
+	assert.Equal(t, 3, p.Items().Count())
+	assert.Equal(t, "Article 4", p.Items().First().Get("title"))
+	assert.Equal(t, "/articles", p.PrevURL())
+	assert.Equal(t, "/articles/page/3", p.NextURL())
+
+	// The last page holds the remaining entries
+	p = articles(7).Paginate(3, 3, "articles")
+	assert.Equal(t, 1, p.Items().Count())
+	assert.False(t, p.HasNext())
+	assert.Equal(t, "", p.NextURL())
+
+	// Page numbers out of range are clamped
+	p = articles(7).Paginate(3, 99, "/articles")
+	assert.Equal(t, 3, p.Current())
+	p = articles(0).Paginate(3, 0, "/")
+	assert.Equal(t, 1, p.Total())
+	assert.Equal(t, 0, p.Items().Count())
+	assert.Equal(t, "/page/2", p.URL(2))
+}
+
+func TestPaginateLinks(t *testing.T) {
+	numbers := func(links []Link) []int {
+		values := make([]int, 0)
+		for _, link := range links {
+			values = append(values, link.Number)
+		}
+		return values
+	}
+
+	assert.Equal(t, []int{3, 4, 5, 6, 7}, numbers(articles(10).Paginate(1, 5, "/articles").Links(5)))
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, numbers(articles(10).Paginate(1, 1, "/articles").Links(5)))
+	assert.Equal(t, []int{6, 7, 8, 9, 10}, numbers(articles(10).Paginate(1, 10, "/articles").Links(5)))
+	assert.Equal(t, []int{1, 2}, numbers(articles(4).Paginate(2, 2, "/articles").Links(5)))
+
+	link := articles(10).Paginate(1, 5, "/articles").Links(1)[0]
+	assert.Equal(t, Link{Number: 5, URL: "/articles/page/5", Current: true}, link)
+}
+
+func TestSplitPageNumber(t *testing.T) {
+	ppath, number, ok := SplitPageNumber("articles/page/2")
+	assert.True(t, ok)
+	assert.Equal(t, "articles", ppath)
+	assert.Equal(t, 2, number)
+
+	ppath, number, ok = SplitPageNumber("/page/3/")
+	assert.True(t, ok)
+	assert.Equal(t, "", ppath)
+	assert.Equal(t, 3, number)
+
+	for _, invalid := range []string{"articles", "articles/page", "articles/page/0", "articles/page/two", "articles/2"} {
+		_, _, ok = SplitPageNumber(invalid)
+		assert.False(t, ok, invalid)
+	}
+}
+
+func TestSliceBounds(t *testing.T) {
+	c := articles(3)
+	assert.Equal(t, 3, c.Slice(-1, 10).Count())
+	assert.Equal(t, 0, c.Slice(5, 10).Count())
+	assert.Equal(t, 0, c.Slice(2, 1).Count())
+}
@@ -0,0 +1,84 @@
+package pager
+
+import (
+	"path"
+	"time"
+)
+
+// IsDraft reports whether the page has draft: true
+func (page *Page) IsDraft() bool {
+	draft, ok := page.Get("draft").(bool)
+	return ok && draft
+}
+
+// PublishAt returns the publish_at frontmatter or the zero time
+func (page *Page) PublishAt() time.Time {
+	return page.Time("publish_at")
+}
+
+// ExpireAt returns the expire_at frontmatter or the zero time
+func (page *Page) ExpireAt() time.Time {
+	return page.Time("expire_at")
+}
+
+// PublishedAt reports whether the page and the directories above it
+// are published at a time, they are not drafts, their publish_at
+// is not after it and their expire_at is after it.
+//
+// Parameters:
+// - at: The time to check the publishing dates against.
+//
+// Returns:
+// - true if the page is published at the time.
+func (page *Page) PublishedAt(at time.Time) bool {
+	if page.IsDraft() {
+		return false
+	}
+	if publish := page.PublishAt(); !publish.IsZero() && publish.After(at) {
+		return false
+	}
+	if expire := page.ExpireAt(); !expire.IsZero() && !expire.After(at) {
+		return false
+	}
+	if page.root == nil || page.Path() == "" {
+		return true
+	}
+
+	// Pages of an unpublished section are unpublished
+	parent, err := page.root.Find(path.Dir("/" + page.Path()))
+	if err != nil || parent.entry.Path() == page.entry.Path() {
+		return true
+	}
+	return parent.PublishedAt(at)
+}
+
+// IsPublished reports whether the page is published now
+func (page *Page) IsPublished() bool {
+	return page.PublishedAt(time.Now())
+}
+
+// ShowDrafts lists unpublished pages like published ones,
+// e.g for editors to see drafts on a live server
+func (root *Root) ShowDrafts(show bool) {
+	root.drafts = show
+}
+
+// Visible reports whether a page is published or drafts are shown
+func (root *Root) Visible(page *Page) bool {
+	return root.drafts || page.IsPublished()
+}
+
+// visible returns the pages of a list that are visible
+func (root *Root) visible(pages []*Page) []*Page {
+	if root.drafts {
+		return pages
+	}
+	now := time.Now()
+	visible := make([]*Page, 0, len(pages))
+	for _, page := range pages {
+		if page.PublishedAt(now) {
+			visible = append(visible, page)
+		}
+	}
+	return visible
+}
@@ -0,0 +1,57 @@
+package pager
+
+import (
+	"julien/driver"
+	"julien/fs"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublished(t *testing.T) {
+	disk := fs.NewMemory("index", "md")
+	disk.Dump("index", []byte("---\ntitle: Home\n---\n"))
+	disk.Dump("articles/index", []byte("---\ntitle: Articles\n---\n"))
+	disk.Dump("articles/live", []byte("---\ntitle: Live\ntags: go\n---\n"))
+	disk.Dump("articles/draft", []byte("---\ntitle: Draft\ndraft: true\ntags: go\n---\n"))
+	disk.Dump("articles/later", []byte("---\ntitle: Later\npublish_at: 2100-01-01\ntags: rust\n---\n"))
+	disk.Dump("articles/gone", []byte("---\ntitle: Gone\nexpire_at: 2000-01-01 10:00\n---\n"))
+	disk.Dump("drafts/index", []byte("---\ntitle: Drafts\ndraft: true\n---\n"))
+	disk.Dump("drafts/one", []byte("---\ntitle: One\n---\n"))
+
+	root := Init(disk, &driver.Yaml{})
+	assert.NoError(t, root.Index())
+
+	pages, err := root.List("articles")
+	assert.NoError(t, err)
+	assert.Len(t, pages, 1)
+	assert.Equal(t, "Live", pages[0].Get("title"))
+
+	// Unpublished pages are found but not visible
+	later, err := root.Find("articles/later")
+	assert.NoError(t, err)
+	assert.False(t, root.Visible(later))
+	assert.True(t, later.PublishedAt(time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)))
+	one, _ := root.Find("drafts/one")
+	assert.False(t, one.IsPublished())
+
+	walked := 0
+	root.Walk(func(page *Page) error {
+		walked++
+		return nil
+	})
+	assert.Equal(t, 3, walked)
+
+	tags, err := root.Taxonomy("tags", "tags")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, tags.Count())
+	assert.Equal(t, 1, tags.Term("go").Count())
+	assert.Nil(t, tags.Term("rust"))
+
+	root.ShowDrafts(true)
+	pages, _ = root.List("articles")
+	assert.Len(t, pages, 4)
+	assert.True(t, root.Visible(later))
+	assert.Equal(t, 2, tags.Count())
+}
@@ -0,0 +1,214 @@
+package pager
+
+import (
+	"fmt"
+	"julien/fs"
+	"julien/markdown"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Taxonomy groups the pages of a Root by the terms of a frontmatter key
+type Taxonomy struct {
+	name  string
+	terms map[string]*Term
+}
+
+// Term is a value of a taxonomy key and the pages having it
+type Term struct {
+	name     string
+	slug     string
+	taxonomy *Taxonomy
+	root     *Root
+	pages    []*Page
+}
+
+// Taxonomy collects the terms of the key frontmatter values of every
+// page. Values are a single term or a list of terms, terms differing
+// in case or spacing only are merged. Terms list the published pages
+// only, terms without published pages are left out.
+//
+// Parameters:
+// - name: The name of the taxonomy, also the base path of its urls.
+// - key: The frontmatter key holding the terms.
+//
+// Returns:
+// - The Taxonomy, kept in memory once the Root is indexed.
+// - An error if the pages cannot be walked.
+func (root *Root) Taxonomy(name string, key string) (*Taxonomy, error) {
+	cachekey := name + ":" + key
+	if taxonomy, ok := root.taxonomies.Get(cachekey); ok {
+		return taxonomy, nil
+	}
+	generation := root.taxonomies.Generation()
+
+	home, err := root.Find("/")
+	if err != nil {
+		return nil, err
+	}
+
+	// Unpublished pages are kept to be listed once published
+	taxonomy := &Taxonomy{name: name, terms: make(map[string]*Term)}
+	err = root.walk(home, root.entries, func(page *Page) error {
+		for _, value := range TermValues(page.Get(key)) {
+			taxonomy.add(value, page)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	root.taxonomies.Set(generation, cachekey, taxonomy)
+	return taxonomy, nil
+}
+
+// Virtual creates a page that has no file in the content mount, e.g
+// the listing of a taxonomy term.
+//
+// Parameters:
+// - ppath: The path the page is served under.
+// - meta: The frontmatter of the page.
+//
+// Returns:
+// - The page.
+// - An error if the path is not a valid page path.
+func (root *Root) Virtual(ppath string, meta map[string]interface{}) (*Page, error) {
+	disk := fs.NewMemory(root.disk.Index(), root.disk.Ext())
+	if err := disk.Dump(ppath, []byte{}); err != nil {
+		return nil, err
+	}
+	entry, err := disk.Find(ppath)
+	if err != nil {
+		return nil, err
+	}
+	return &Page{
+		meta:     meta,
+		entry:    entry,
+		root:     root,
+		driver:   root.driver,
+		extended: make(map[interface{}]interface{}),
+		specs:    &sync.Map{},
+		document: &atomic.Pointer[markdown.Document]{},
+	}, nil
+}
+
+// TermValues returns the terms of a frontmatter value
+func TermValues(value interface{}) []string {
+	values := make([]string, 0)
+	switch value := value.(type) {
+	case string:
+		values = append(values, value)
+	case []interface{}:
+		for _, item := range value {
+			if item != nil {
+				values = append(values, fmt.Sprint(item))
+			}
+		}
+	case []string:
+		values = append(values, value...)
+	}
+
+	terms := make([]string, 0, len(values))
+	for _, term := range values {
+		if term = strings.TrimSpace(term); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// Slug returns the url path element of a term
+func Slug(term string) string {
+	return strings.Join(strings.Fields(strings.ToLower(strings.ReplaceAll(term, "/", " "))), "-")
+}
+
+func (t *Taxonomy) add(value string, page *Page) {
+	slug := Slug(value)
+	term, ok := t.terms[slug]
+	if !ok {
+		term = &Term{name: value, slug: slug, taxonomy: t, root: page.root, pages: make([]*Page, 0)}
+		t.terms[slug] = term
+	}
+	for _, added := range term.pages {
+		if added == page {
+			return
+		}
+	}
+	term.pages = append(term.pages, page)
+}
+
+func (t *Taxonomy) Name() string {
+	return t.name
+}
+
+func (t *Taxonomy) URL() string {
+	return "/" + t.name
+}
+
+// Count returns the number of terms
+func (t *Taxonomy) Count() int {
+	return len(t.Terms())
+}
+
+// Terms returns the terms sorted by name
+func (t *Taxonomy) Terms() []*Term {
+	terms := make([]*Term, 0, len(t.terms))
+	for _, term := range t.terms {
+		if term.Count() > 0 {
+			terms = append(terms, term)
+		}
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		return terms[i].slug < terms[j].slug
+	})
+	return terms
+}
+
+// ByCount returns the terms with the most pages first
+func (t *Taxonomy) ByCount() []*Term {
+	terms := t.Terms()
+	sort.SliceStable(terms, func(i, j int) bool {
+		return terms[i].Count() > terms[j].Count()
+	})
+	return terms
+}
+
+// Term returns the term of a name or slug or nil if no page has it
+func (t *Taxonomy) Term(name string) *Term {
+	term, ok := t.terms[Slug(name)]
+	if !ok || term.Count() == 0 {
+		return nil
+	}
+	return term
+}
+
+// Name returns the term as first written in a page
+func (term *Term) Name() string {
+	return term.name
+}
+
+func (term *Term) Slug() string {
+	return term.slug
+}
+
+func (term *Term) URL() string {
+	return path.Join(term.taxonomy.URL(), term.slug)
+}
+
+// Count returns the number of pages having the term
+func (term *Term) Count() int {
+	return len(term.Pages())
+}
+
+// Pages returns the published pages having the term
+func (term *Term) Pages() []*Page {
+	return term.root.visible(copies(term.pages))
+}
+
+// Collection returns the pages having the term
+func (term *Term) Collection() *Collection {
+	return NewPageCollection(term.Pages())
+}
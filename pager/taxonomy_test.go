@@ -0,0 +1,61 @@
+package pager
+
+import (
+	"julien/driver"
+	"julien/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaxonomy(t *testing.T) {
+	disk := fs.NewMemory("index", "md")
+	disk.Dump("index", []byte("---\ntitle: Home\n---\n"))
+	disk.Dump("articles/index", []byte("---\ntitle: Articles\n---\n"))
+	disk.Dump("articles/one", []byte("---\ntitle: One\ntags: [Go, Web Dev]\n---\n"))
+	disk.Dump("articles/two", []byte("---\ntitle: Two\ntags: [go, go]\n---\n"))
+	disk.Dump("about", []byte("---\ntitle: About\ntags: web dev\n---\n"))
+
+	root := Init(disk, &driver.Yaml{})
+	assert.NoError(t, root.Index())
+
+	tags, err := root.Taxonomy("tags", "tags")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, tags.Count())
+
+	terms := tags.Terms()
+	assert.Equal(t, "go", terms[0].Slug())
+	assert.Equal(t, "web-dev", terms[1].Slug())
+	assert.Equal(t, "/tags/web-dev", terms[1].URL())
+
+	// Terms are merged by slug and pages listed once
+	golang := tags.Term("GO")
+	assert.Equal(t, "Go", golang.Name())
+	assert.Equal(t, 2, golang.Count())
+	assert.Equal(t, "Two", golang.Collection().Where("title", "Two").First().Get("title"))
+	assert.Nil(t, tags.Term("rust"))
+
+	// Indexed taxonomies are dropped with the pages
+	disk.Dump("articles/three", []byte("---\ntitle: Three\ntags: rust\n---\n"))
+	tags, _ = root.Taxonomy("tags", "tags")
+	assert.Nil(t, tags.Term("rust"))
+	root.Invalidate()
+	tags, _ = root.Taxonomy("tags", "tags")
+	assert.NotNil(t, tags.Term("rust"))
+	assert.Equal(t, "rust", tags.ByCount()[2].Slug())
+}
+
+func TestVirtual(t *testing.T) {
+	disk := fs.NewMemory("index", "md")
+	root := Init(disk, &driver.Yaml{})
+
+	page, err := root.Virtual("tags/go", map[string]interface{}{"title": "Go", "view": "term"})
+	assert.NoError(t, err)
+	assert.Equal(t, "/tags/go", page.APath())
+	assert.Equal(t, "term", page.View())
+	assert.Equal(t, "Go", page.Get("title"))
+
+	// Virtual pages are not part of the content
+	_, err = root.Find("tags/go")
+	assert.Error(t, err)
+}
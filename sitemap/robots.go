@@ -0,0 +1,87 @@
+package sitemap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ROBOTS is the file name of the robots exclusion rules
+const ROBOTS string = "robots.txt"
+
+// Robots renders the robots.txt of a site from its robots frontmatter,
+// either the raw file content or a list of rules e.g
+// robots: [{user-agent: "*", disallow: [/admin], crawl-delay: 10}].
+// Every crawler is allowed without rules.
+//
+// Parameters:
+// - value: The robots frontmatter value.
+// - sitemap: The absolute url of the sitemap, skipped if empty.
+//
+// Returns:
+// - The content of robots.txt.
+// - An error for a malformed robots value.
+func Robots(value interface{}, sitemap string) ([]byte, error) {
+	lines := make([]string, 0)
+	switch value := value.(type) {
+	case nil:
+		lines = append(lines, "User-agent: *", "Disallow:")
+	case string:
+		lines = append(lines, strings.TrimRight(value, "\n"))
+		// Keep the sitemap of the raw content
+		if strings.Contains(strings.ToLower(value), "sitemap:") {
+			sitemap = ""
+		}
+	case []interface{}:
+		for i, rule := range value {
+			group, ok := rule.(map[interface{}]interface{})
+			if !ok {
+				return nil, fmt.Errorf("robots rule must be a map: %v", rule)
+			}
+			if i > 0 {
+				lines = append(lines, "")
+			}
+			agents := list(group["user-agent"])
+			if len(agents) == 0 {
+				agents = []string{"*"}
+			}
+			for _, agent := range agents {
+				lines = append(lines, "User-agent: "+agent)
+			}
+			for _, allow := range list(group["allow"]) {
+				lines = append(lines, "Allow: "+allow)
+			}
+			disallows := list(group["disallow"])
+			for _, disallow := range disallows {
+				lines = append(lines, "Disallow: "+disallow)
+			}
+			if _, ok := group["allow"]; !ok && len(disallows) == 0 {
+				lines = append(lines, "Disallow:")
+			}
+			if delay, ok := group["crawl-delay"]; ok {
+				lines = append(lines, fmt.Sprintf("Crawl-delay: %v", delay))
+			}
+		}
+	default:
+		return nil, fmt.Errorf("robots must be a string or a list of rules: %v", value)
+	}
+	if sitemap != "" {
+		lines = append(lines, "", "Sitemap: "+sitemap)
+	}
+	return []byte(strings.Join(lines, "\n") + "\n"), nil
+}
+
+// list reads a frontmatter value of one or many strings
+func list(value interface{}) []string {
+	switch value := value.(type) {
+	case nil:
+		return []string{}
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			values = append(values, fmt.Sprint(v))
+		}
+		return values
+	default:
+		return []string{fmt.Sprint(value)}
+	}
+}
@@ -0,0 +1,155 @@
+package sitemap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FILE is the sitemap of a site, an index of the numbered
+// sitemaps once a site has more urls than the limit
+const FILE string = "sitemap.xml"
+
+// LIMIT is the number of urls of a sitemap allowed by the protocol
+const LIMIT int = 50000
+
+const NAMESPACE string = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// URL is an entry of a sitemap
+type URL struct {
+	Loc        string // Absolute url of the page
+	LastMod    time.Time
+	ChangeFreq string
+	Priority   float64
+}
+
+// Name returns the file name of a numbered sitemap e.g sitemap-2.xml
+func Name(number int) string {
+	return "sitemap-" + strconv.Itoa(number) + ".xml"
+}
+
+// IsFile reports whether a file name is the sitemap or a numbered sitemap
+func IsFile(file string) bool {
+	if file == FILE {
+		return true
+	}
+	number, ok := strings.CutPrefix(file, "sitemap-")
+	if !ok {
+		return false
+	}
+	number, ok = strings.CutSuffix(number, ".xml")
+	n, err := strconv.Atoi(number)
+	return ok && err == nil && n > 0 && Name(n) == file
+}
+
+// Files renders the sitemap documents of a site by file name, a single
+// sitemap.xml or a sitemap.xml index of sitemap-<n>.xml files holding
+// up to limit urls each.
+//
+// Parameters:
+// - root: The absolute url of the site the numbered sitemaps are under.
+// - urls: The urls of the site.
+// - limit: The number of urls per sitemap, LIMIT if not positive.
+//
+// Returns:
+// - The documents by file name.
+// - An error if a document cannot be rendered.
+func Files(root string, urls []URL, limit int) (map[string][]byte, error) {
+	if limit < 1 || limit > LIMIT {
+		limit = LIMIT
+	}
+	files := make(map[string][]byte)
+	if len(urls) <= limit {
+		body, err := Render(urls)
+		if err != nil {
+			return nil, err
+		}
+		files[FILE] = body
+		return files, nil
+	}
+
+	index := sitemapIndex{Xmlns: NAMESPACE}
+	for start := 0; start < len(urls); start += limit {
+		end := min(start+limit, len(urls))
+		name := Name(len(index.Sitemaps) + 1)
+		body, err := Render(urls[start:end])
+		if err != nil {
+			return nil, err
+		}
+		files[name] = body
+
+		entry := sitemapEntry{Loc: strings.TrimRight(root, "/") + "/" + name}
+		if lastmod := newest(urls[start:end]); !lastmod.IsZero() {
+			entry.LastMod = lastmod.Format(time.RFC3339)
+		}
+		index.Sitemaps = append(index.Sitemaps, entry)
+	}
+	body, err := document(index)
+	if err != nil {
+		return nil, err
+	}
+	files[FILE] = body
+	return files, nil
+}
+
+type urlset struct {
+	XMLName xml.Name   `xml:"urlset"`
+	Xmlns   string     `xml:"xmlns,attr"`
+	URLs    []urlEntry `xml:"url"`
+}
+
+type urlEntry struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod,omitempty"`
+	ChangeFreq string `xml:"changefreq,omitempty"`
+	Priority   string `xml:"priority,omitempty"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name       `xml:"sitemapindex"`
+	Xmlns    string         `xml:"xmlns,attr"`
+	Sitemaps []sitemapEntry `xml:"sitemap"`
+}
+
+type sitemapEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// Render renders a sitemap of urls
+func Render(urls []URL) ([]byte, error) {
+	doc := urlset{Xmlns: NAMESPACE, URLs: make([]urlEntry, 0, len(urls))}
+	for _, url := range urls {
+		entry := urlEntry{Loc: url.Loc, ChangeFreq: url.ChangeFreq}
+		if !url.LastMod.IsZero() {
+			entry.LastMod = url.LastMod.Format(time.RFC3339)
+		}
+		if url.Priority > 0 {
+			entry.Priority = fmt.Sprintf("%.1f", url.Priority)
+		}
+		doc.URLs = append(doc.URLs, entry)
+	}
+	return document(doc)
+}
+
+// newest returns the latest modification time of urls
+func newest(urls []URL) time.Time {
+	lastmod := time.Time{}
+	for _, url := range urls {
+		if url.LastMod.After(lastmod) {
+			lastmod = url.LastMod
+		}
+	}
+	return lastmod
+}
+
+// document marshals an xml document with its header
+func document(doc interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
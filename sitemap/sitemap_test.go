@@ -0,0 +1,88 @@
+package sitemap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func urls(count int) []URL {
+	list := make([]URL, 0, count)
+	for i := 1; i <= count; i++ {
+		list = append(list, URL{
+			Loc:     fmt.Sprintf("https://julien.dev/page-%d", i),
+			LastMod: time.Date(2024, 5, i, 0, 0, 0, 0, time.UTC),
+		})
+	}
+	return list
+}
+
+func TestFiles(t *testing.T) {
+	files, err := Files("https://julien.dev", urls(2), 0)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	var set struct {
+		URLs []struct {
+			Loc     string `xml:"loc"`
+			LastMod string `xml:"lastmod"`
+		} `xml:"url"`
+	}
+	assert.NoError(t, xml.Unmarshal(files[FILE], &set))
+	assert.Len(t, set.URLs, 2)
+	assert.Equal(t, "https://julien.dev/page-1", set.URLs[0].Loc)
+	assert.Equal(t, "2024-05-01T00:00:00Z", set.URLs[0].LastMod)
+}
+
+func TestFilesIndex(t *testing.T) {
+	files, err := Files("https://julien.dev/", urls(5), 2)
+	assert.NoError(t, err)
+	assert.Len(t, files, 4)
+	assert.Contains(t, files, Name(3))
+
+	var index struct {
+		XMLName  xml.Name `xml:"sitemapindex"`
+		Sitemaps []struct {
+			Loc     string `xml:"loc"`
+			LastMod string `xml:"lastmod"`
+		} `xml:"sitemap"`
+	}
+	assert.NoError(t, xml.Unmarshal(files[FILE], &index))
+	assert.Len(t, index.Sitemaps, 3)
+	assert.Equal(t, "https://julien.dev/sitemap-2.xml", index.Sitemaps[1].Loc)
+	assert.Equal(t, "2024-05-04T00:00:00Z", index.Sitemaps[1].LastMod)
+	assert.Equal(t, 1, strings.Count(string(files[Name(3)]), "<url>"))
+}
+
+func TestIsFile(t *testing.T) {
+	assert.True(t, IsFile("sitemap.xml"))
+	assert.True(t, IsFile("sitemap-12.xml"))
+	assert.False(t, IsFile("sitemap-0.xml"))
+	assert.False(t, IsFile("sitemap-01.xml"))
+	assert.False(t, IsFile("sitemap-a.xml"))
+	assert.False(t, IsFile("articles/sitemap.xml"))
+}
+
+func TestRobots(t *testing.T) {
+	body, err := Robots(nil, "https://julien.dev/sitemap.xml")
+	assert.NoError(t, err)
+	assert.Equal(t, "User-agent: *\nDisallow:\n\nSitemap: https://julien.dev/sitemap.xml\n", string(body))
+
+	body, err = Robots([]interface{}{
+		map[interface{}]interface{}{"user-agent": "*", "disallow": []interface{}{"/admin", "/drafts"}},
+		map[interface{}]interface{}{"user-agent": []interface{}{"BadBot"}, "disallow": "/", "crawl-delay": 10},
+	}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "User-agent: *\nDisallow: /admin\nDisallow: /drafts\n\nUser-agent: BadBot\nDisallow: /\nCrawl-delay: 10\n", string(body))
+
+	body, err = Robots("User-agent: *\nSitemap: https://julien.dev/map.xml\n", "https://julien.dev/sitemap.xml")
+	assert.NoError(t, err)
+	assert.Equal(t, "User-agent: *\nSitemap: https://julien.dev/map.xml\n", string(body))
+
+	_, err = Robots(10, "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,42 @@
+package spam
+
+import (
+	"html"
+	"strconv"
+	"strings"
+)
+
+// SCRIPT solves the proof of work of a form on submit, the counter is
+// the first one whose sha256 with the token has enough zero bits
+const SCRIPT string = `<script>(function(){` +
+	`var w=document.currentScript.previousElementSibling,f=w.form,e=new TextEncoder();` +
+	`function z(h){for(var c=0,i=0;i<h.length;i++){if(h[i]){return c+Math.clz32(h[i])-24}c+=8}return c}` +
+	`f.addEventListener("submit",async function(s){if(w.value){return}s.preventDefault();` +
+	`for(var n=0;;n++){var h=new Uint8Array(await crypto.subtle.digest("SHA-256",e.encode(w.dataset.token+":"+n)));` +
+	`if(z(h)>=+w.dataset.bits){w.value=n;break}}f.submit()})})();</script>`
+
+// Fields returns the hidden inputs of the defenses of a form to
+// place inside the form element.
+//
+// Parameters:
+// - options: The defenses of the form.
+// - token: A token of the form, signed at render.
+//
+// Returns:
+// - The html of the fields, empty without defenses.
+func Fields(options Options, token string) string {
+	out := strings.Builder{}
+	if options.Honeypot != "" {
+		out.WriteString(`<div aria-hidden="true" style="position:absolute;left:-10000px;top:auto;width:1px;height:1px;overflow:hidden">`)
+		out.WriteString(`<input type="text" name="` + html.EscapeString(options.Honeypot) + `" tabindex="-1" autocomplete="off" value="">`)
+		out.WriteString(`</div>`)
+	}
+	if options.Signed() {
+		out.WriteString(`<input type="hidden" name="` + TOKEN_FIELD + `" value="` + html.EscapeString(token) + `">`)
+	}
+	if options.Work > 0 {
+		out.WriteString(`<input type="hidden" name="` + WORK_FIELD + `" value="" data-token="` + html.EscapeString(token) + `" data-bits="` + strconv.Itoa(options.Work) + `">`)
+		out.WriteString(SCRIPT)
+	}
+	return out.String()
+}
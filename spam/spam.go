@@ -0,0 +1,282 @@
+package spam
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fields of the form posted with a submission
+
+const TOKEN_FIELD string = "_julien_token"
+
+const WORK_FIELD string = "_julien_work"
+
+// MAX_AGE is how long a token is valid unless configured
+const MAX_AGE time.Duration = 24 * time.Hour
+
+// MAX_BITS caps the proof of work difficulty
+const MAX_BITS int = 32
+
+// Reasons of a rejected submission
+
+const HONEYPOT string = "honeypot"
+
+const TOKEN string = "token"
+
+const TOO_FAST string = "too_fast"
+
+const EXPIRED string = "expired"
+
+const REPLAY string = "replay"
+
+const WORK string = "work"
+
+// Options are the spam defenses of a form, set under its spam key e.g
+// spam: {honeypot: website, min_time: 3s, max_age: 24h, work: 16, quarantine: true}
+type Options struct {
+	Honeypot   string        // Hidden field that must stay empty
+	MinTime    time.Duration // Least time between the render and the post of a form
+	MaxAge     time.Duration // Longest time between the render and the post of a form
+	Work       int           // Leading zero bits of the proof of work, zero disables it
+	Quarantine bool          // Keep rejected submissions apart in the data mount
+}
+
+// Parse reads the spam key of a form.
+//
+// Parameters:
+// - value: The value of the spam key, nil disables every defense.
+//
+// Returns:
+// - The Options.
+// - An error for a malformed spam key.
+func Parse(value interface{}) (Options, error) {
+	options := Options{}
+	if value == nil {
+		return options, nil
+	}
+	spec, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return options, fmt.Errorf("spam must be a map")
+	}
+	options.Honeypot, _ = spec["honeypot"].(string)
+	options.Quarantine, _ = spec["quarantine"].(bool)
+	var err error
+	if options.MinTime, err = duration(spec["min_time"]); err != nil {
+		return options, fmt.Errorf("spam min_time: %w", err)
+	}
+	if options.MaxAge, err = duration(spec["max_age"]); err != nil {
+		return options, fmt.Errorf("spam max_age: %w", err)
+	}
+	if options.MaxAge == 0 {
+		options.MaxAge = MAX_AGE
+	}
+	if work, ok := spec["work"]; ok {
+		if options.Work, ok = work.(int); !ok || options.Work < 0 || options.Work > MAX_BITS {
+			return options, fmt.Errorf("spam work must be a number of bits up to %d", MAX_BITS)
+		}
+	}
+	return options, nil
+}
+
+// duration reads a duration e.g 3s or a number of seconds
+func duration(value interface{}) (time.Duration, error) {
+	switch value := value.(type) {
+	case nil:
+		return 0, nil
+	case int:
+		return time.Duration(value) * time.Second, nil
+	case string:
+		return time.ParseDuration(value)
+	default:
+		return 0, fmt.Errorf("invalid duration: %v", value)
+	}
+}
+
+// Signed reports whether submissions need a signed token
+func (options Options) Signed() bool {
+	return options.MinTime > 0 || options.Work > 0
+}
+
+// Enabled reports whether the form has a defense
+func (options Options) Enabled() bool {
+	return options.Honeypot != "" || options.Signed()
+}
+
+// Submission is what a post carries for the defenses of its form
+type Submission struct {
+	Honeypot string // Value of the honeypot field
+	Token    string
+	Work     string // Counter solving the proof of work of the token
+}
+
+// Guard signs the render time of forms and checks the submissions,
+// it is safe for concurrent use
+type Guard struct {
+	secret []byte
+	lock   sync.Mutex
+	used   map[string]time.Time        // Tokens already posted until they expire
+	counts map[string]map[string]int64 // Rejections by form and reason
+}
+
+// NewGuard creates a Guard signing with a secret, a random
+// secret is used when empty.
+//
+// Parameters:
+// - secret: The secret of the config.
+//
+// Returns:
+// - The Guard.
+func NewGuard(secret string) *Guard {
+	key := []byte(secret)
+	if secret == "" {
+		key = make([]byte, 32)
+		rand.Read(key)
+	}
+	return &Guard{
+		secret: key,
+		used:   make(map[string]time.Time),
+		counts: make(map[string]map[string]int64),
+	}
+}
+
+// Token signs the render time of a form, the token is the time,
+// a nonce and the signature of the form name, time and nonce.
+//
+// Parameters:
+// - form: The name of the form.
+// - now: The render time.
+//
+// Returns:
+// - The token.
+func (g *Guard) Token(form string, now time.Time) string {
+	nonce := make([]byte, 12)
+	rand.Read(nonce)
+	payload := strconv.FormatInt(now.Unix(), 10) + "." + base64.RawURLEncoding.EncodeToString(nonce)
+	return payload + "." + g.sign(form, payload)
+}
+
+func (g *Guard) sign(form string, payload string) string {
+	mac := hmac.New(sha256.New, g.secret)
+	mac.Write([]byte(form + "\n" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Check runs the defenses of a form on a submission.
+//
+// Parameters:
+// - form: The name of the form.
+// - options: The defenses of the form.
+// - submission: The values of the post.
+// - now: The time of the post.
+//
+// Returns:
+// - The reason the submission is rejected, empty if it passes.
+func (g *Guard) Check(form string, options Options, submission Submission, now time.Time) string {
+	if options.Honeypot != "" && strings.TrimSpace(submission.Honeypot) != "" {
+		return HONEYPOT
+	}
+	if !options.Signed() {
+		return ""
+	}
+
+	parts := strings.Split(submission.Token, ".")
+	if len(parts) != 3 {
+		return TOKEN
+	}
+	expected := g.sign(form, parts[0]+"."+parts[1])
+	if !hmac.Equal([]byte(expected), []byte(parts[2])) {
+		return TOKEN
+	}
+	unix, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return TOKEN
+	}
+	rendered := time.Unix(unix, 0)
+	if now.Sub(rendered) < options.MinTime {
+		return TOO_FAST
+	}
+	if now.Sub(rendered) > options.MaxAge {
+		return EXPIRED
+	}
+	if options.Work > 0 && !Solved(submission.Token, submission.Work, options.Work) {
+		return WORK
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	for token, expires := range g.used {
+		if now.After(expires) {
+			delete(g.used, token)
+		}
+	}
+	if _, ok := g.used[submission.Token]; ok {
+		return REPLAY
+	}
+	g.used[submission.Token] = rendered.Add(options.MaxAge)
+	return ""
+}
+
+// Solved reports whether the sha256 of the token, a colon and the
+// counter starts with a number of zero bits
+func Solved(token string, counter string, zeros int) bool {
+	if _, err := strconv.ParseUint(counter, 10, 64); err != nil {
+		return false
+	}
+	sum := sha256.Sum256([]byte(token + ":" + counter))
+	return leading(sum[:]) >= zeros
+}
+
+// Solve finds the counter of a proof of work, browsers solve it
+// with the script of the form fields
+func Solve(token string, zeros int) string {
+	for counter := uint64(0); ; counter++ {
+		value := strconv.FormatUint(counter, 10)
+		sum := sha256.Sum256([]byte(token + ":" + value))
+		if leading(sum[:]) >= zeros {
+			return value
+		}
+	}
+}
+
+func leading(sum []byte) int {
+	count := 0
+	for _, b := range sum {
+		if b != 0 {
+			return count + bits.LeadingZeros8(b)
+		}
+		count += 8
+	}
+	return count
+}
+
+// Reject counts a rejected submission of a form
+func (g *Guard) Reject(form string, reason string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.counts[form] == nil {
+		g.counts[form] = make(map[string]int64)
+	}
+	g.counts[form][reason]++
+}
+
+// Counts returns the rejected submissions by form and reason since start
+func (g *Guard) Counts() map[string]map[string]int64 {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	counts := make(map[string]map[string]int64, len(g.counts))
+	for form, reasons := range g.counts {
+		counts[form] = make(map[string]int64, len(reasons))
+		for reason, count := range reasons {
+			counts[form][reason] = count
+		}
+	}
+	return counts
+}
@@ -0,0 +1,79 @@
+package spam
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	options, err := Parse(nil)
+	assert.NoError(t, err)
+	assert.False(t, options.Enabled())
+
+	options, err = Parse(map[interface{}]interface{}{"honeypot": "website", "min_time": "3s", "work": 8, "quarantine": true})
+	assert.NoError(t, err)
+	assert.Equal(t, Options{Honeypot: "website", MinTime: 3 * time.Second, MaxAge: MAX_AGE, Work: 8, Quarantine: true}, options)
+	assert.True(t, options.Signed())
+
+	options, err = Parse(map[interface{}]interface{}{"min_time": 5, "max_age": "1h"})
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Second, options.MinTime)
+	assert.Equal(t, time.Hour, options.MaxAge)
+
+	_, err = Parse(map[interface{}]interface{}{"work": 64})
+	assert.Error(t, err)
+	_, err = Parse(map[interface{}]interface{}{"min_time": "soon"})
+	assert.Error(t, err)
+	_, err = Parse("on")
+	assert.Error(t, err)
+}
+
+func TestCheck(t *testing.T) {
+	guard := NewGuard("secret")
+	options := Options{Honeypot: "website", MinTime: 3 * time.Second, MaxAge: time.Hour}
+	now := time.Now()
+	token := guard.Token("contact", now)
+
+	assert.Equal(t, HONEYPOT, guard.Check("contact", options, Submission{Honeypot: "http://spam", Token: token}, now.Add(time.Minute)))
+	assert.Equal(t, TOKEN, guard.Check("contact", options, Submission{}, now.Add(time.Minute)))
+	assert.Equal(t, TOKEN, guard.Check("other", options, Submission{Token: token}, now.Add(time.Minute)))
+	assert.Equal(t, TOKEN, guard.Check("contact", options, Submission{Token: NewGuard("").Token("contact", now)}, now.Add(time.Minute)))
+	assert.Equal(t, TOO_FAST, guard.Check("contact", options, Submission{Token: token}, now.Add(time.Second)))
+	assert.Equal(t, EXPIRED, guard.Check("contact", options, Submission{Token: token}, now.Add(2*time.Hour)))
+	assert.Equal(t, "", guard.Check("contact", options, Submission{Token: token}, now.Add(time.Minute)))
+	assert.Equal(t, REPLAY, guard.Check("contact", options, Submission{Token: token}, now.Add(time.Minute)))
+
+	// Only the honeypot is checked without a signed option
+	assert.Equal(t, "", guard.Check("contact", Options{Honeypot: "website"}, Submission{}, now))
+}
+
+func TestWork(t *testing.T) {
+	guard := NewGuard("secret")
+	options := Options{Work: 12, MaxAge: time.Hour}
+	now := time.Now()
+	token := guard.Token("contact", now)
+	counter := Solve(token, 12)
+	assert.True(t, Solved(token, counter, 12))
+	assert.False(t, Solved(token, "x", 0))
+
+	assert.Equal(t, WORK, guard.Check("contact", options, Submission{Token: token}, now))
+	assert.Equal(t, "", guard.Check("contact", options, Submission{Token: token, Work: counter}, now))
+}
+
+func TestRejectAndFields(t *testing.T) {
+	guard := NewGuard("secret")
+	guard.Reject("contact", HONEYPOT)
+	guard.Reject("contact", HONEYPOT)
+	guard.Reject("contact", TOO_FAST)
+	assert.Equal(t, map[string]map[string]int64{"contact": {HONEYPOT: 2, TOO_FAST: 1}}, guard.Counts())
+
+	assert.Equal(t, "", Fields(Options{}, "t"))
+	fields := Fields(Options{Honeypot: "web\"site", MinTime: time.Second, Work: 10}, "1.a.b")
+	assert.Contains(t, fields, `name="web&#34;site"`)
+	assert.Contains(t, fields, `name="_julien_token" value="1.a.b"`)
+	assert.Contains(t, fields, `data-bits="10"`)
+	assert.True(t, strings.HasSuffix(fields, "</script>"))
+}
@@ -6,9 +6,11 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"julien/markdown"
 	"math/rand"
 	"regexp"
 	"strings"
+	"sync/atomic"
 	"time"
 	"unicode/utf8"
 
@@ -16,7 +18,6 @@ import (
 
 	"github.com/extemporalgenome/slug"
 	"github.com/flosch/go-humanize"
-	"github.com/russross/blackfriday/v2"
 )
 
 func PogoInit() {
@@ -42,8 +43,28 @@ func PogoInit() {
 	pongo2.RegisterFilter("ordinal", filterOrdinal)
 }
 
+// markdowner renders the markdown filter, filters of pongo
+// are global so the last renderer set is used
+var markdowner atomic.Pointer[markdown.Markdown]
+
+// UseMarkdown sets the renderer of the markdown filter
+func UseMarkdown(md *markdown.Markdown) {
+	markdowner.Store(md)
+}
+
 func filterMarkdown(in *pongo2.Value, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
-	return pongo2.AsSafeValue(string(blackfriday.Run([]byte(in.String())))), nil
+	md := markdowner.Load()
+	if md == nil {
+		md = markdown.Fallback()
+	}
+	html, err := md.Render(in.String())
+	if err != nil {
+		return nil, &pongo2.Error{
+			Sender:    "filter:markdown",
+			OrigError: err,
+		}
+	}
+	return pongo2.AsSafeValue(html), nil
 }
 
 func filterUpercase(in *pongo2.Value, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
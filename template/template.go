@@ -2,7 +2,10 @@ package template
 
 import (
 	"fmt"
+	"io/fs"
 	"julien/driver"
+	jfs "julien/fs"
+	"net/http"
 	"os"
 	"path"
 
@@ -18,6 +21,9 @@ type Template struct {
 	Meta    map[string]interface{}
 	Content string
 	Path    string
+	// Read only file system the template is loaded from,
+	// nil when the template is read from Path on disk
+	FS fs.FS
 }
 
 func Find(ppath string, index ...string) (*Template, error) {
@@ -44,12 +50,113 @@ func Find(ppath string, index ...string) (*Template, error) {
 		Path:    ppath,
 		Meta:    *frontmatter,
 		Content: content,
+		FS:      os.DirFS(ppath),
 	}, nil
 
 }
 
+// FindFS loads the template at ppath within a read only file system
+func FindFS(fsys fs.FS, ppath string, index ...string) (*Template, error) {
+	yamler := driver.Yaml{}
+	if len(index) == 0 {
+		index = append(index, "index.md")
+	}
+	sub, err := fs.Sub(fsys, path.Clean(ppath))
+	if err != nil {
+		return nil, err
+	}
+	cbytes, err := fs.ReadFile(sub, index[0])
+	if err != nil {
+		return nil, err
+	}
+	frontmatter, content, err := yamler.Parse(cbytes)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{
+		Path:    ppath,
+		Meta:    *frontmatter,
+		Content: content,
+		FS:      sub,
+	}, nil
+}
+
+// Load loads the template name from the templates directory root.
+// Views, layouts, partials and public files are looked up in the
+// override templates first, then in the template itself and last
+// in the parent templates declared by the parent key of index.md.
+// The index.md meta of a template extends the meta of its parent.
+//
+// Parameters:
+// - root: The templates directory.
+// - ppath: The path of the templates directory.
+// - name: The name of the template.
+// - overrides: The names of the templates overriding the template files.
+//
+// Returns:
+// - A pointer to the loaded template.
+// - An error if a template cannot be read or the parents form a cycle.
+func Load(root fs.FS, ppath string, name string, overrides ...string) (*Template, error) {
+	layers := make([]fs.FS, 0)
+	for _, override := range overrides {
+		info, err := fs.Stat(root, override)
+		if err != nil {
+			return nil, fmt.Errorf("template override %s: %w", override, err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("template override %s is not a directory", override)
+		}
+		sub, err := fs.Sub(root, override)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, sub)
+	}
+
+	// Walk up the parents, the meta of a parent
+	// is overwritten by the meta of its children
+	chain := make([]*Template, 0)
+	seen := make(map[string]bool)
+	for tname := name; tname != ""; {
+		if seen[tname] {
+			return nil, fmt.Errorf("template parent cycle: %s", tname)
+		}
+		seen[tname] = true
+		tmpl, err := FindFS(root, tname)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, tmpl)
+		layers = append(layers, tmpl.FS)
+		tname = tmpl.GetString("parent", "")
+	}
+
+	meta := make(map[string]interface{})
+	for i := len(chain) - 1; i >= 0; i-- {
+		for key, value := range chain[i].Meta {
+			meta[key] = value
+		}
+	}
+	delete(meta, "parent")
+
+	return &Template{
+		Path:    path.Join(ppath, name),
+		Meta:    meta,
+		Content: chain[0].Content,
+		FS:      jfs.NewOverlay(layers...),
+	}, nil
+}
+
+// Public returns the public files of the template
+func (tmpl *Template) Public() (fs.FS, error) {
+	return fs.Sub(tmpl.FS, "public")
+}
+
 func (tmpl *Template) Engine(reload bool) fiber.Views {
 	name := tmpl.GetString("type", "html")
+	if tmpl.FS != nil {
+		return tmpl.engineFS(name, reload)
+	}
 	switch name {
 	case "pongo":
 		PogoInit()
@@ -80,6 +187,39 @@ func (tmpl *Template) Engine(reload bool) fiber.Views {
 	}
 }
 
+// engineFS creates the view engine of a template read from its file system
+func (tmpl *Template) engineFS(name string, reload bool) fiber.Views {
+	hfs := http.FS(tmpl.FS)
+	switch name {
+	case "pongo":
+		PogoInit()
+		vengine := django.NewFileSystem(hfs, "."+tmpl.GetString("ext", "html"))
+		vengine.Reload(reload)
+		return vengine
+
+	case "html":
+		vengine := html.NewFileSystem(hfs, "."+tmpl.GetString("ext", "html"))
+		vengine.Reload(reload)
+		return vengine
+
+	case "mustache":
+		vengine := mustache.NewFileSystem(hfs, "."+tmpl.GetString("ext", "mustache"))
+		vengine.Reload(reload)
+		return vengine
+
+	case "jet":
+		vengine := jet.NewFileSystem(hfs, "."+tmpl.GetString("ext", "jet"))
+		vengine.Reload(reload)
+		return vengine
+
+	default:
+		log.Error("template " + name + " engine not found default to html")
+		vengine := html.NewFileSystem(hfs, "."+tmpl.GetString("ext", "html"))
+		vengine.Reload(reload)
+		return vengine
+	}
+}
+
 func (tmpl *Template) Get(key string, defaultValue ...interface{}) interface{} {
 	value, ok := tmpl.Meta[key]
 	if ok {
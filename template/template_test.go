@@ -1,7 +1,9 @@
 package template
 
 import (
+	"io/fs"
 	"testing"
+	"testing/fstest"
 )
 
 func TestTemplate_Get(t *testing.T) {
@@ -107,3 +109,62 @@ func TestTemplate_GetInt64(t *testing.T) {
 		t.Errorf("Expected value 456, got %v", value)
 	}
 }
+
+func themes() fstest.MapFS {
+	return fstest.MapFS{
+		"base/index.md":             {Data: []byte("---\ntype: html\next: html\nauthor: base\n---\nBase")},
+		"base/views/page.html":      {Data: []byte("base page")},
+		"base/views/post.html":      {Data: []byte("base post")},
+		"base/public/base.css":      {Data: []byte("base css")},
+		"client/index.md":           {Data: []byte("---\nparent: base\nauthor: client\n---\nClient")},
+		"client/views/post.html":    {Data: []byte("client post")},
+		"overrides/views/page.html": {Data: []byte("override page")},
+		"loop/index.md":             {Data: []byte("---\nparent: loop\n---\n")},
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tmpl, err := Load(themes(), "templates", "client", "overrides")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tmpl.Path != "templates/client" {
+		t.Errorf("Expected path 'templates/client', got '%v'", tmpl.Path)
+	}
+	if tmpl.GetString("author") != "client" {
+		t.Errorf("Expected author 'client', got '%v'", tmpl.GetString("author"))
+	}
+	if tmpl.GetString("type") != "html" {
+		t.Errorf("Expected type inherited from parent 'html', got '%v'", tmpl.GetString("type"))
+	}
+	if tmpl.Content != "Client" {
+		t.Errorf("Expected content 'Client', got '%v'", tmpl.Content)
+	}
+
+	files := map[string]string{
+		"views/page.html": "override page",
+		"views/post.html": "client post",
+		"public/base.css": "base css",
+	}
+	for name, expected := range files {
+		content, err := fs.ReadFile(tmpl.FS, name)
+		if err != nil {
+			t.Errorf("Expected %s to be found, got %v", name, err)
+		} else if string(content) != expected {
+			t.Errorf("Expected %s to be '%s', got '%s'", name, expected, content)
+		}
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	if _, err := Load(themes(), "templates", "loop"); err == nil {
+		t.Errorf("Expected parent cycle error")
+	}
+	if _, err := Load(themes(), "templates", "client", "missing"); err == nil {
+		t.Errorf("Expected missing override error")
+	}
+	if _, err := Load(themes(), "templates", "missing"); err == nil {
+		t.Errorf("Expected missing template error")
+	}
+}
@@ -0,0 +1,58 @@
+package utils
+
+import "sync"
+
+// Cache is a concurrent map of parsed values dropped as a whole when
+// their source changes. Values are stored with the generation read
+// before loading them so a value loaded while the cache was cleared
+// is not kept. A disabled cache misses every lookup.
+type Cache[T any] struct {
+	lock       sync.RWMutex
+	enabled    bool
+	generation uint64
+	values     map[string]T
+}
+
+func NewCache[T any]() *Cache[T] {
+	return &Cache[T]{values: make(map[string]T)}
+}
+
+// Enable starts keeping the values stored in the cache
+func (c *Cache[T]) Enable() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.enabled = true
+}
+
+// Get returns the value cached for key
+func (c *Cache[T]) Get(key string) (T, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	value, ok := c.values[key]
+	return value, ok && c.enabled
+}
+
+// Generation returns the generation to store a value loaded next with
+func (c *Cache[T]) Generation() uint64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.generation
+}
+
+// Set caches the value of key unless the cache was
+// cleared since the generation value was loaded at
+func (c *Cache[T]) Set(generation uint64, key string, value T) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.enabled && c.generation == generation {
+		c.values[key] = value
+	}
+}
+
+// Clear drops every cached value
+func (c *Cache[T]) Clear() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.generation++
+	c.values = make(map[string]T)
+}
@@ -0,0 +1,30 @@
+package utils
+
+import "testing"
+
+func TestCache(t *testing.T) {
+	cache := NewCache[int]()
+
+	// Disabled caches keep nothing
+	cache.Set(cache.Generation(), "one", 1)
+	if _, ok := cache.Get("one"); ok {
+		t.Errorf("Cache.Get() on a disabled cache found a value")
+	}
+
+	cache.Enable()
+	cache.Set(cache.Generation(), "one", 1)
+	if got, ok := cache.Get("one"); !ok || got != 1 {
+		t.Errorf("Cache.Get() = %v, %v, want 1, true", got, ok)
+	}
+
+	// Values loaded before a clear are stale
+	generation := cache.Generation()
+	cache.Clear()
+	cache.Set(generation, "two", 2)
+	if _, ok := cache.Get("two"); ok {
+		t.Errorf("Cache.Set() kept a value loaded before Cache.Clear()")
+	}
+	if _, ok := cache.Get("one"); ok {
+		t.Errorf("Cache.Clear() kept a value")
+	}
+}
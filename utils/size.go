@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SIZE_UNITS are the multipliers of the size units, powers of 1024
+var SIZE_UNITS = map[string]int64{
+	"":   1,
+	"B":  1,
+	"KB": 1 << 10,
+	"MB": 1 << 20,
+	"GB": 1 << 30,
+}
+
+// ParseSize parses a number of bytes or a size with a unit e.g 512KB.
+//
+// Parameters:
+// - value: An int or a string like 10MB, units are case insensitive.
+//
+// Returns:
+// - The size in bytes.
+// - An error if the value is not a positive size.
+func ParseSize(value interface{}) (int64, error) {
+	switch value := value.(type) {
+	case int:
+		if value > 0 {
+			return int64(value), nil
+		}
+	case int64:
+		if value > 0 {
+			return value, nil
+		}
+	case string:
+		size := strings.ToUpper(strings.ReplaceAll(value, " ", ""))
+		number := strings.TrimRight(size, "KMGB")
+		unit, ok := SIZE_UNITS[size[len(number):]]
+		count, err := strconv.ParseInt(number, 10, 64)
+		if ok && err == nil && count > 0 {
+			return count * unit, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid size: %v", value)
+}
@@ -89,3 +89,31 @@ func TestArrayIncludesString(t *testing.T) {
 		})
 	}
 }
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  int64
+		fails bool
+	}{
+		{value: 512, want: 512},
+		{value: "512", want: 512},
+		{value: "2KB", want: 2048},
+		{value: "10 mb", want: 10 << 20},
+		{value: "1GB", want: 1 << 30},
+		{value: "0MB", fails: true},
+		{value: "MB", fails: true},
+		{value: "5TB", fails: true},
+		{value: -1, fails: true},
+		{value: 1.5, fails: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.value)
+		if (err != nil) != tt.fails {
+			t.Errorf("ParseSize(%v) error = %v, fails %v", tt.value, err, tt.fails)
+		}
+		if got != tt.want {
+			t.Errorf("ParseSize(%v) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
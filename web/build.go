@@ -0,0 +1,185 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"julien/fs"
+	"julien/pager"
+	"julien/sitemap"
+	jutils "julien/utils"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+)
+
+// Build renders every page of the content mount to an index.html file
+// under out and copies the static files, the template public files and
+// the content assets next to them so the site can be served statically.
+// The 404 page is also written to 404.html at the root of out and
+// paginated pages are written to <path>/page/<number>/index.html.
+// Taxonomy pages are rendered like the pages of the content mount
+// and the feeds of sections are written next to their index.html.
+// The sitemaps and robots.txt are written at the root of out.
+// Pages failing to render are skipped and reported in the error.
+//
+// Parameters:
+// - out: The directory the site is exported to.
+//
+// Returns:
+// - The number of pages rendered.
+// - An error if pages cannot be rendered or a file cannot be written.
+func (web *Web) Build(out string) (int, error) {
+	count := 0
+	views := web.template.Engine(false)
+	if err := views.Load(); err != nil {
+		return count, err
+	}
+
+	// Keep rendering the other pages of a broken one
+	failed := make([]error, 0)
+	render := func(page *pager.Page) error {
+		view, layout := web.ViewPaths(page)
+		dir := filepath.Join(out, filepath.FromSlash(page.Path()))
+
+		// The first page tells how many pages follow
+		for number, total := 1, 1; number <= total; number++ {
+			pagination := NewPagination(page, number)
+			vparams := web.ViewParams(page)
+			vparams["Paginate"] = pagination.Paginate
+
+			html := bytes.NewBuffer(nil)
+			if err := views.Render(html, view, vparams, layout); err != nil {
+				failed = append(failed, fmt.Errorf("page %s: %w", page.APath(), err))
+				return nil
+			}
+			total = pagination.Total()
+
+			target := filepath.Join(dir, "index.html")
+			if number > 1 {
+				target = filepath.Join(dir, pager.PAGE_PATH, strconv.Itoa(number), "index.html")
+			}
+			if err := write(target, html.Bytes()); err != nil {
+				return err
+			}
+			if page.Path() == "404" {
+				if err := write(filepath.Join(out, "404.html"), html.Bytes()); err != nil {
+					return err
+				}
+			}
+			count++
+		}
+
+		options, ok, err := FeedOptions(page)
+		if err != nil {
+			failed = append(failed, fmt.Errorf("feed %s: %w", page.APath(), err))
+			return nil
+		}
+		if ok {
+			doc := web.Feed(page, options)
+			for _, format := range options.Formats {
+				body, err := doc.Render(format)
+				if err != nil {
+					return err
+				}
+				if err := write(filepath.Join(dir, format.File), body); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := web.Content().Walk(render); err != nil {
+		return count, err
+	}
+
+	taxonomies, err := web.TaxonomyPages()
+	if err != nil {
+		return count, err
+	}
+	for _, page := range taxonomies {
+		if err := render(page); err != nil {
+			return count, err
+		}
+	}
+
+	robots, err := web.Robots()
+	if err != nil {
+		return count, err
+	}
+	if err := write(filepath.Join(out, sitemap.ROBOTS), robots); err != nil {
+		return count, err
+	}
+	sitemaps, err := web.Sitemaps()
+	if err != nil {
+		return count, err
+	}
+	for name, body := range sitemaps {
+		if err := write(filepath.Join(out, name), body); err != nil {
+			return count, err
+		}
+	}
+
+	if static, err := web.Static(); err == nil {
+		if err := export(filepath.Join(out, "static"), static, nil); err != nil {
+			return count, err
+		}
+	}
+
+	if public, err := web.template.Public(); err == nil {
+		if err := export(filepath.Join(out, "public"), public, nil); err != nil {
+			return count, err
+		}
+	}
+
+	if assets, ok := web.assets.(interface{ FS() iofs.FS }); ok {
+		err := export(out, assets.FS(), func(name string) bool {
+			ext := path.Ext(name)
+			if ext == "" || !jutils.ArrayIncludes(web.AllowedFiles(), ext[1:]) {
+				return false
+			}
+			// Skip symlinks leading outside of the content mount
+			if disk, ok := web.assets.(*fs.Disk); ok {
+				_, err := disk.Resolve(name)
+				return err == nil
+			}
+			return true
+		})
+		if err != nil {
+			return count, err
+		}
+	}
+
+	return count, errors.Join(failed...)
+}
+
+// write writes a file of an exported site creating its directories
+func write(target string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	return fs.WriteFile(target, content, 0644, false)
+}
+
+// export copies the files of fsys accepted by filter to the out directory
+func export(out string, fsys iofs.FS, filter func(string) bool) error {
+	return iofs.WalkDir(fsys, ".", func(name string, entry iofs.DirEntry, err error) error {
+		if err != nil {
+			// A missing static or public directory has nothing to export
+			if name == "." && os.IsNotExist(err) {
+				return iofs.SkipAll
+			}
+			return err
+		}
+		if entry.IsDir() || (filter != nil && !filter(name)) {
+			return nil
+		}
+		content, err := iofs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		return write(filepath.Join(out, filepath.FromSlash(name)), content)
+	})
+}
@@ -0,0 +1,328 @@
+package web
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"julien/fs"
+	"julien/julien"
+	"julien/webhook"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// RELOAD_PATH is the event stream open pages listen to for reloads
+const RELOAD_PATH string = "/_julien/reload"
+
+// RELOAD_DELAY is how long changes are collected before reloading
+const RELOAD_DELAY time.Duration = 100 * time.Millisecond
+
+// RELOAD_SCRIPT is injected into html pages served in dev mode
+const RELOAD_SCRIPT string = `<script>
+(function () {
+	var events = new EventSource("` + RELOAD_PATH + `");
+	events.addEventListener("reload", function () { location.reload(); });
+})();
+</script>`
+
+// OVERLAY is the page shown in dev mode for templates failing to render
+const OVERLAY string = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Julien: %s</title></head>
+<body style="margin:0">
+<div style="position:fixed;inset:0;overflow:auto;padding:2em;background:rgba(20,20,20,.95);color:#f8f8f2;font-family:monospace">
+<h2 style="color:#ff5555;margin-top:0">%s</h2>
+<pre style="white-space:pre-wrap">%s</pre>
+<p style="color:#aaa">The page reloads once the error is fixed.</p>
+</div>
+</body>
+</html>`
+
+// Dev holds the state of a Web serving a site in dev mode
+type Dev struct {
+	site    string // Absolute path of the site file
+	config  string // Absolute path of the config file
+	watcher *fs.Watcher
+	err     error // Error of the last reload, shown until fixed
+	lock    sync.Mutex
+	clients map[chan bool]bool
+}
+
+// Dev switches web to dev mode. The mounts, the template directory,
+// the site file and the config are watched, the site file and config
+// are reloaded in place on change, open pages are told to reload and
+// pages failing to render show the error instead of a blank 500
+func (web *Web) Dev(sitepath string, configpath string) error {
+	site, err := filepath.Abs(sitepath)
+	if err != nil {
+		return err
+	}
+	config, err := filepath.Abs(configpath)
+	if err != nil {
+		return err
+	}
+	web.dev = &Dev{
+		site:    site,
+		config:  config,
+		clients: make(map[chan bool]bool),
+	}
+	return web.watch()
+}
+
+// watch watches the paths of the current config
+func (web *Web) watch() error {
+	if web.dev.watcher != nil {
+		web.dev.watcher.Close()
+	}
+
+	paths := []string{web.dev.site, web.dev.config}
+	// Submissions to the data mount do not change pages
+	for _, mp := range []julien.MountPoint{web.config.Content, web.config.Forms} {
+		if mp.Storage != "memory" && mp.Path != "" {
+			paths = append(paths, mp.Path)
+		}
+	}
+	// Overrides and parents are siblings of the template
+	paths = append(paths, web.config.StaticPath(), web.config.Template.Path)
+
+	watcher, err := fs.Watch(RELOAD_DELAY, web.changed, paths...)
+	if err != nil {
+		return err
+	}
+	web.dev.watcher = watcher
+	return nil
+}
+
+// changed reloads what changed and tells open pages to reload
+func (web *Web) changed(changed []string, err error) {
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	config, tmpl := false, false
+	templates, _ := filepath.Abs(web.config.Template.Path)
+	for _, name := range changed {
+		if name == web.dev.site || name == web.dev.config {
+			config = true
+		} else if strings.HasPrefix(name, templates+string(filepath.Separator)) {
+			tmpl = true
+		}
+	}
+
+	// Content, forms and data only need open pages to be
+	// reloaded once the indexed pages and forms are dropped
+	if config {
+		err = web.Reload()
+		if err == nil {
+			web.index()
+			err = web.watch()
+		}
+	} else if tmpl {
+		err = web.ReloadTemplate()
+	}
+	web.content.Invalidate()
+	web.forms.Invalidate()
+
+	web.lock.Lock()
+	if config || tmpl {
+		web.dev.err = err
+	}
+	web.lock.Unlock()
+
+	if err != nil {
+		log.Error(err)
+	}
+	web.dev.broadcast()
+}
+
+// Reload reads the site file and config again and replaces the
+// mounts, forms, pages and template of a Web in dev mode
+func (web *Web) Reload() error {
+	config, err := julien.FindConfig(web.dev.config)
+	if err != nil {
+		return err
+	}
+	site, err := julien.FindSite(web.dev.site)
+	if err != nil {
+		return err
+	}
+	fresh, err := rebuild(config, site)
+	if err != nil {
+		return err
+	}
+
+	// Deliveries being attempted finish on the old data mount,
+	// reporting them takes the read lock so stop before locking
+	queued := web.queue != nil
+	if queued {
+		web.queue.Stop()
+	}
+
+	web.lock.Lock()
+	stale := []fs.Storage{web.assets, web.forms.Disk(), web.forms.Data()}
+	web.config = fresh.config
+	web.site = fresh.site
+	web.forms = fresh.forms
+	web.content = fresh.content
+	web.content.ShowDrafts(web.drafts)
+	web.template = fresh.template
+	web.assets = fresh.assets
+	web.mailer = fresh.mailer
+	if web.outbox != nil {
+		// Mails already queued are still sent
+		web.outbox.Close()
+	}
+	web.outbox = outgoing(web.mailer)
+	web.guard = fresh.guard
+	web.limiter = fresh.limiter
+	web.views = fresh.template.Engine(true)
+	if queued {
+		web.queue = webhook.NewQueue(web.forms.Data(), web.Delivered)
+	}
+	web.lock.Unlock()
+
+	// Requests holding the old mounts are done once locked
+	for _, disk := range stale {
+		if closer, ok := disk.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				log.Error(err)
+			}
+		}
+	}
+	if queued {
+		web.queue.Start()
+	}
+	return nil
+}
+
+// ReloadTemplate loads the template of a Web in dev mode again
+func (web *Web) ReloadTemplate() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recovered(r)
+		}
+	}()
+	tmpl := LoadTemplate(web.config, nil)
+
+	web.lock.Lock()
+	defer web.lock.Unlock()
+	web.template = tmpl
+	web.views = tmpl.Engine(true)
+	return nil
+}
+
+// rebuild creates a Web from disk turning its panics into an error
+func rebuild(config *julien.Julien, site *julien.Site) (fresh Web, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recovered(r)
+		}
+	}()
+	return New(config, site), nil
+}
+
+func recovered(r interface{}) error {
+	if err, ok := r.(error); ok {
+		return err
+	}
+	return fmt.Errorf("%v", r)
+}
+
+// Overlay renders an error page for errors of a Web in dev mode
+func (web *Web) Overlay(ctx *fiber.Ctx, err error) error {
+	log.Error(err)
+	title := html.EscapeString(strings.SplitN(err.Error(), "\n", 2)[0])
+	ctx.Status(fiber.StatusInternalServerError)
+	ctx.Type("html", "utf-8")
+	return ctx.SendString(fmt.Sprintf(OVERLAY, title, title, html.EscapeString(err.Error())))
+}
+
+// Events streams a reload event to an open page for each change
+func (web *Web) Events(ctx *fiber.Ctx) error {
+	ctx.Set(fiber.HeaderContentType, "text/event-stream")
+	ctx.Set(fiber.HeaderCacheControl, "no-cache")
+	ctx.Set(fiber.HeaderConnection, "keep-alive")
+
+	reload := web.dev.subscribe()
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer web.dev.unsubscribe(reload)
+		ping := time.NewTicker(15 * time.Second)
+		defer ping.Stop()
+
+		fmt.Fprint(w, ": connected\n\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+		for {
+			select {
+			case <-reload:
+				fmt.Fprint(w, "event: reload\ndata: reload\n\n")
+			case <-ping.C:
+				// Notice closed pages
+				fmt.Fprint(w, ": ping\n\n")
+			}
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+	return nil
+}
+
+// inject adds the reload script to the html pages of a Web in dev mode
+func (web *Web) inject(ctx *fiber.Ctx) error {
+	if err := ctx.Next(); err != nil {
+		return err
+	}
+
+	resp := ctx.Response()
+	ctype := string(resp.Header.ContentType())
+	if !strings.HasPrefix(ctype, fiber.MIMETextHTML) || len(resp.Header.Peek(fiber.HeaderContentEncoding)) > 0 {
+		return nil
+	}
+	body := string(resp.Body())
+	if body == "" {
+		return nil
+	}
+
+	if index := strings.LastIndex(body, "</body>"); index >= 0 {
+		body = body[:index] + RELOAD_SCRIPT + body[index:]
+	} else {
+		body = body + RELOAD_SCRIPT
+	}
+	resp.SetBodyString(body)
+	return nil
+}
+
+func (dev *Dev) subscribe() chan bool {
+	dev.lock.Lock()
+	defer dev.lock.Unlock()
+	client := make(chan bool, 1)
+	dev.clients[client] = true
+	return client
+}
+
+func (dev *Dev) unsubscribe(client chan bool) {
+	dev.lock.Lock()
+	defer dev.lock.Unlock()
+	delete(dev.clients, client)
+}
+
+func (dev *Dev) broadcast() {
+	dev.lock.Lock()
+	defer dev.lock.Unlock()
+	for client := range dev.clients {
+		// Pages with a pending reload need a single one
+		select {
+		case client <- true:
+		default:
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package web
+
+import (
+	"julien/feed"
+	"julien/pager"
+	"net/http"
+	"path"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// FeedLink is a feed of a section for autodiscovery links
+type FeedLink struct {
+	Title string
+	URL   string
+	Type  string
+}
+
+// FeedOptions returns the feed options of a section page, set by
+// its feed frontmatter or the feed key of its page: block
+func FeedOptions(section *pager.Page) (feed.Options, bool, error) {
+	if !section.IsDir() {
+		return feed.Options{}, false, nil
+	}
+	value := section.Get("feed")
+	if value == nil {
+		if spec, ok := section.Get("page").(map[interface{}]interface{}); ok {
+			value = spec["feed"]
+		}
+	}
+	return feed.Parse(value)
+}
+
+// Feed returns the feed of the newest pages of a section
+func (web *Web) Feed(section *pager.Page, options feed.Options) *feed.Feed {
+	site := web.Site()
+	title := section.GetString("title", site.GetString("title"))
+	if section.Path() == "" {
+		title = site.GetString("title", title)
+	}
+
+	doc := &feed.Feed{
+		Title:       title,
+		Description: section.GetString("description", site.GetString("description")),
+		URL:         site.URL(section.APath()),
+		Author:      site.GetString("author"),
+		Language:    site.GetString("lang"),
+		Items:       make([]feed.Item, 0),
+	}
+
+	pages := section.Collection().SortByDate("desc").Slice(0, options.Limit)
+	for _, page := range pages.Entries() {
+		url := site.URL(page.APath())
+		item := feed.Item{
+			ID:        url,
+			URL:       url,
+			Title:     page.GetString("title", page.Name()),
+			Summary:   page.GetString("summary", page.GetString("description")),
+			Content:   string(page.HTML()),
+			Author:    page.GetString("author"),
+			Tags:      pager.TermValues(page.Get("tags")),
+			Published: page.Date(),
+			Updated:   page.Updated(),
+		}
+		if item.Updated.After(doc.Updated) {
+			doc.Updated = item.Updated
+		}
+		doc.Items = append(doc.Items, item)
+	}
+	return doc
+}
+
+// FeedLinks returns the feeds of a section or of the section of a page
+func (web *Web) FeedLinks(page *pager.Page) []FeedLink {
+	links := make([]FeedLink, 0)
+
+	// Pages of a section link to its feeds, sections
+	// without feeds like articles/my-article/ as well
+	section := page
+	options, ok, err := FeedOptions(section)
+	if err == nil && !ok && page.Path() != "" {
+		section, err = web.Content().Find(path.Dir("/" + page.Path()))
+		if err != nil {
+			return links
+		}
+		options, ok, err = FeedOptions(section)
+	}
+	if err != nil || !ok {
+		return links
+	}
+	title := section.GetString("title", web.Site().GetString("title"))
+	for _, format := range options.Formats {
+		links = append(links, FeedLink{
+			Title: title,
+			URL:   feed.Link(section.Path(), format),
+			Type:  format.Type,
+		})
+	}
+	return links
+}
+
+// SendFeed sends a feed document of a section, it reports
+// false if the path is not the feed of a section
+func (web *Web) SendFeed(ctx *fiber.Ctx, name string) (bool, error) {
+	dir, file := path.Split(name)
+	format, ok := feed.Find(file)
+	if !ok {
+		return false, nil
+	}
+	section, err := web.Content().Find("/" + dir)
+	if err != nil || !web.Content().Visible(section) {
+		return false, nil
+	}
+	options, ok, err := FeedOptions(section)
+	if err != nil {
+		log.Error(err)
+		return true, ctx.SendStatus(fiber.StatusInternalServerError)
+	}
+	if !ok || !options.Has(format) {
+		return false, nil
+	}
+
+	doc := web.Feed(section, options)
+	body, err := doc.Render(format)
+	if err != nil {
+		log.Error(err)
+		return true, ctx.SendStatus(fiber.StatusInternalServerError)
+	}
+	ctx.Set(fiber.HeaderContentType, format.Type+"; charset=utf-8")
+	if !doc.Updated.IsZero() {
+		ctx.Set(fiber.HeaderLastModified, doc.Updated.UTC().Format(http.TimeFormat))
+	}
+	return true, ctx.Send(body)
+}
@@ -0,0 +1,126 @@
+package web
+
+import (
+	"crypto/subtle"
+	"io"
+	"julien/form"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/basicauth"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// FILES_PATH is the root of the downloads of form files e.g
+// /_julien/files/contact-us/bob.files/cv/1-resume.pdf
+const FILES_PATH string = "/_julien/files"
+
+// Admin guards a route with basic auth of the admin users of the
+// config, the route is not found without admin users
+func (web *Web) Admin() fiber.Handler {
+	auth := basicauth.New(basicauth.Config{
+		Realm:      "Julien",
+		Authorizer: web.Authorize,
+	})
+	return func(ctx *fiber.Ctx) error {
+		web.lock.RLock()
+		if len(web.config.Admin.Users) == 0 {
+			defer web.lock.RUnlock()
+			return render(web, ctx, "404")
+		}
+		web.lock.RUnlock()
+		return auth(ctx)
+	}
+}
+
+// Authorize reports whether a password is the one of an admin user,
+// passwords starting with $2 are bcrypt hashes
+func (web *Web) Authorize(user string, password string) bool {
+	web.lock.RLock()
+	expected, ok := web.config.Admin.Users[user]
+	web.lock.RUnlock()
+	if !ok || expected == "" {
+		return false
+	}
+	if strings.HasPrefix(expected, "$2") {
+		return bcrypt.CompareHashAndPassword([]byte(expected), []byte(password)) == nil
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(password)) == 1
+}
+
+// SendFile sends a file of a form submission as an attachment
+// with the type detected from its content
+func (web *Web) SendFile(ctx *fiber.Ctx) error {
+	ppath, err := url.PathUnescape(ctx.Params("*"))
+	if err != nil {
+		return render(web, ctx, "404")
+	}
+	ppath = strings.TrimPrefix(path.Clean("/"+ppath), "/")
+	name, _, _ := strings.Cut(ppath, "/")
+	fm, err := web.Forms().Find(name)
+	if err != nil {
+		return render(web, ctx, "404")
+	}
+	content, err := fm.File(ppath)
+	if err != nil {
+		return render(web, ctx, "404")
+	}
+	ctx.Attachment(path.Base(ppath))
+	ctx.Set(fiber.HeaderContentType, mimetype.Detect(content).String())
+	ctx.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	ctx.Set(fiber.HeaderCacheControl, "private, no-store")
+	return ctx.Send(content)
+}
+
+// uploads reads the files of the file fields of a multipart
+// request, empty file inputs are left out
+func uploads(ctx *fiber.Ctx, fields map[string]form.FileField) (map[string][]form.Upload, error) {
+	files := make(map[string][]form.Upload)
+	if len(fields) == 0 || !strings.HasPrefix(ctx.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		return files, nil
+	}
+	multipart, err := ctx.MultipartForm()
+	if err != nil {
+		return nil, err
+	}
+	for name := range fields {
+		for _, header := range multipart.File[name] {
+			if header.Filename == "" && header.Size == 0 {
+				continue
+			}
+			file, err := header.Open()
+			if err != nil {
+				return nil, err
+			}
+			content, err := io.ReadAll(file)
+			file.Close()
+			if err != nil {
+				return nil, err
+			}
+			files[name] = append(files[name], form.Upload{Name: header.Filename, Content: content})
+		}
+	}
+	return files, nil
+}
+
+// attach stores the checked files of a submission and records them
+// in its frontmatter, the files are removed if one cannot be stored
+func attach(doc *form.Doc, files map[string][]form.Upload) error {
+	if len(files) == 0 {
+		return nil
+	}
+	for name, uploads := range files {
+		if _, err := doc.Attach(name, uploads); err != nil {
+			doc.Detach()
+			return err
+		}
+	}
+	if err := doc.Save(); err != nil {
+		doc.Detach()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package web
+
+import (
+	"fmt"
+	"julien/form"
+	"julien/limit"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/gofiber/fiber/v2/middleware/session"
+)
+
+// LIMIT_KEY is the FormData error key of submissions over the rate limit
+const LIMIT_KEY string = "rate_limit"
+
+// Limited counts a submission against the rate limit of its form,
+// the rate_limit key of the form overrides the one of the config.
+// Submissions keyed by a field without a value are keyed by ip.
+//
+// Parameters:
+// - ctx: The request of the submission.
+// - fm: The form submitted.
+// - sess: The session of the request.
+// - data: The posted json values.
+//
+// Returns:
+// - How long until the key may submit again, zero if the submission is allowed.
+// - A function taking back the counted submission, e.g when it fails to be stored.
+// - An error for a malformed rate_limit key.
+func (web *Web) Limited(ctx *fiber.Ctx, fm *form.Form, sess *session.Session, data map[string]interface{}) (time.Duration, func(), error) {
+	refund := func() {}
+	base, err := web.config.RateLimit.Rule()
+	if err != nil {
+		return 0, refund, err
+	}
+	rule, err := limit.Parse(fm.Get("rate_limit"), base)
+	if err != nil {
+		return 0, refund, fmt.Errorf("form %s: %w", fm.Name(), err)
+	}
+	if !rule.Enabled() {
+		return 0, refund, nil
+	}
+
+	key := ctx.IP()
+	switch {
+	case rule.Key == limit.KEY_SESSION:
+		key = sess.ID()
+	case rule.Key != limit.KEY_IP:
+		if value := strings.ToLower(strings.TrimSpace(field(ctx, data, rule.Field()))); value != "" {
+			key = value
+		}
+	}
+	now := time.Now()
+	allowed, wait, err := web.limiter.Allow(fm.Name(), rule, key, now)
+	if err != nil {
+		// Submissions are not lost to a broken limiter state
+		log.Error(err)
+	}
+	if allowed {
+		if err == nil {
+			limiter := web.limiter
+			refund = func() {
+				if err := limiter.Refund(fm.Name(), rule, key, now); err != nil {
+					log.Error(err)
+				}
+			}
+		}
+		return 0, refund, nil
+	}
+	log.Warnf("form %s: rate limited submission from %s", fm.Name(), ctx.IP())
+	return wait, refund, nil
+}
@@ -0,0 +1,123 @@
+package web
+
+import (
+	"bytes"
+	"julien/form"
+	"julien/mail"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// Notify mails a submission to the recipients of its form and the auto
+// reply of the form to the submitter, the mails are rendered with the
+// template of the site and sent in the background through the outbox.
+// Auto replies are rate limited by submitter address.
+func (web *Web) Notify(fm *form.Form, doc *form.Doc) {
+	if web.outbox == nil {
+		return
+	}
+	post := Post{Form: fm, Data: doc, Timestamp: time.Now().Unix()}
+	messages := make([]mail.Message, 0, 2)
+
+	reply, err := fm.Reply()
+	if err != nil {
+		log.Error(err)
+	}
+	field := form.REPLY_FIELD
+	if reply != nil {
+		field = reply.Field
+	}
+	submitter := doc.Address(field)
+
+	notify, err := fm.Notify()
+	if err != nil {
+		log.Error(err)
+	}
+	if notify != nil {
+		msg, err := web.message(notify, post)
+		if err != nil {
+			log.Error(err)
+		} else {
+			msg.To = notify.To
+			msg.ReplyTo = submitter
+			messages = append(messages, msg)
+		}
+	}
+	if reply != nil && submitter != "" && web.Replies(fm, reply, submitter) {
+		msg, err := web.message(reply, post)
+		if err != nil {
+			log.Error(err)
+		} else {
+			msg.To = []string{submitter}
+			messages = append(messages, msg)
+		}
+	}
+	if len(messages) == 0 {
+		return
+	}
+
+	for _, msg := range messages {
+		if !web.outbox.Post(msg) {
+			log.Errorf("form %s: %s: mail to %v: outbox full", fm.Name(), doc.Name(), msg.To)
+		}
+	}
+}
+
+// Replies counts an auto reply against the rate limit of the reply,
+// so the form cannot be used to mail any address over and over.
+//
+// Parameters:
+// - fm: The form submitted.
+// - reply: The auto reply of the form.
+// - address: The address of the submitter.
+//
+// Returns:
+// - Whether the auto reply may be sent.
+func (web *Web) Replies(fm *form.Form, reply *form.Mail, address string) bool {
+	allowed, _, err := web.limiter.Allow(fm.Name()+"@reply", reply.Limit, strings.ToLower(address), time.Now())
+	if err != nil {
+		log.Error(err)
+	}
+	if !allowed {
+		log.Warnf("form %s: rate limited auto reply to %s", fm.Name(), address)
+	}
+	return allowed
+}
+
+// outgoing creates the outbox of a mailer, nil without one
+func outgoing(mailer *mail.Mailer) *mail.Outbox {
+	if mailer == nil {
+		return nil
+	}
+	return mail.NewOutbox(mailer, mail.OUTBOX, mail.SENDERS, func(msg mail.Message, err error) {
+		log.Errorf("mail %q to %v: %v", msg.Subject, msg.To, err)
+	})
+}
+
+// message renders the template of a mail with the Post of the
+// submission, or the submission dump without a template
+func (web *Web) message(spec *form.Mail, post Post) (mail.Message, error) {
+	msg := mail.Message{Subject: spec.Subject}
+	if spec.Template == "" {
+		content, err := post.Data.Dump()
+		if err != nil {
+			return msg, err
+		}
+		msg.Text = string(content)
+		return msg, nil
+	}
+	out := bytes.NewBuffer(nil)
+	binding := fiber.Map{
+		"Post":  post,
+		"Site":  web.Site(),
+		"Forms": web.Forms(),
+	}
+	if err := web.views.Render(out, spec.Template, binding); err != nil {
+		return msg, err
+	}
+	msg.HTML = out.String()
+	return msg, nil
+}
@@ -0,0 +1,72 @@
+package web
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"julien/pager"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// PREVIEW_KEY is the query key of preview tokens e.g /articles/draft?preview=<token>
+const PREVIEW_KEY string = "preview"
+
+// PreviewToken signs a token showing an unpublished page until it
+// expires, the token is the expiry and the signature of the page
+// path and expiry with the preview secret of the config.
+//
+// Parameters:
+// - secret: The preview secret of the config.
+// - ppath: The path of the page e.g /articles/draft.
+// - expires: The time the token stops being valid.
+//
+// Returns:
+// - The token.
+// - An error if the secret is empty.
+func PreviewToken(secret string, ppath string, expires time.Time) (string, error) {
+	if secret == "" {
+		return "", errors.New("preview secret not configured")
+	}
+	unix := strconv.FormatInt(expires.Unix(), 10)
+	return unix + "." + sign(secret, ppath, unix), nil
+}
+
+// VerifyPreview reports whether a token shows a page at a time
+func VerifyPreview(secret string, ppath string, token string, at time.Time) bool {
+	unix, signature, ok := strings.Cut(token, ".")
+	if secret == "" || !ok {
+		return false
+	}
+	expires, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil || at.Unix() >= expires {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(sign(secret, ppath, unix)))
+}
+
+func sign(secret string, ppath string, unix string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(path.Clean("/"+ppath) + "\n" + unix))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ShowDrafts shows unpublished pages like published ones
+func (web *Web) ShowDrafts(show bool) {
+	web.drafts = show
+	web.content.ShowDrafts(show)
+}
+
+// Previewing reports whether a request carries a valid preview token of a page
+func (web *Web) Previewing(ctx *fiber.Ctx, page *pager.Page) bool {
+	token := ctx.Query(PREVIEW_KEY)
+	if token == "" {
+		return false
+	}
+	return VerifyPreview(web.config.Preview.Secret, page.APath(), token, time.Now())
+}
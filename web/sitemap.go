@@ -0,0 +1,128 @@
+package web
+
+import (
+	iofs "io/fs"
+	"julien/pager"
+	"julien/sitemap"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// Indexed reports whether a page belongs in the sitemap, error pages,
+// unpublished pages and pages with sitemap: false or noindex: true
+// are left out, also when drafts are shown
+func Indexed(page *pager.Page) bool {
+	switch page.Path() {
+	case "404", "500":
+		return false
+	}
+	if sitemap, ok := page.Get("sitemap").(bool); ok && !sitemap {
+		return false
+	}
+	if !page.IsPublished() {
+		return false
+	}
+	if noindex, ok := page.Get("noindex").(bool); ok && noindex {
+		return false
+	}
+	return true
+}
+
+// SitemapURLs returns the sitemap entries of the content pages
+// and taxonomy pages with the absolute urls of the site
+func (web *Web) SitemapURLs() ([]sitemap.URL, error) {
+	site := web.Site()
+	urls := make([]sitemap.URL, 0)
+	err := web.Content().Walk(func(page *pager.Page) error {
+		if !Indexed(page) {
+			return nil
+		}
+		lastmod := page.Updated()
+		if lastmod.IsZero() {
+			lastmod = page.Timestamp()
+		}
+		url := sitemap.URL{
+			Loc:        site.URL(page.APath()),
+			LastMod:    lastmod,
+			ChangeFreq: page.GetString("changefreq"),
+		}
+		switch priority := page.Get("priority").(type) {
+		case float64:
+			url.Priority = priority
+		case int:
+			url.Priority = float64(priority)
+		}
+		urls = append(urls, url)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Taxonomy pages are not files, they have no modification time
+	taxonomies, err := web.TaxonomyPages()
+	if err != nil {
+		return nil, err
+	}
+	for _, page := range taxonomies {
+		urls = append(urls, sitemap.URL{Loc: site.URL(page.APath())})
+	}
+	return urls, nil
+}
+
+// Sitemaps returns the sitemap documents of the site by file name,
+// split into an index past the sitemap_limit of the site
+func (web *Web) Sitemaps() (map[string][]byte, error) {
+	urls, err := web.SitemapURLs()
+	if err != nil {
+		return nil, err
+	}
+	site := web.Site()
+	return sitemap.Files(site.URL(), urls, site.GetInt("sitemap_limit", sitemap.LIMIT))
+}
+
+// Robots returns the robots.txt of the static files or
+// the one of the robots frontmatter of the site
+func (web *Web) Robots() ([]byte, error) {
+	if static, err := web.Static(); err == nil {
+		if body, err := iofs.ReadFile(static, sitemap.ROBOTS); err == nil {
+			return body, nil
+		}
+	}
+	site := web.Site()
+	location := ""
+	if site.URL() != "" {
+		location = site.URL(sitemap.FILE)
+	}
+	return sitemap.Robots(site.Get("robots"), location)
+}
+
+// SendSitemap sends a sitemap or robots.txt, it reports
+// false if the path is not a sitemap of the site
+func (web *Web) SendSitemap(ctx *fiber.Ctx, name string) (bool, error) {
+	if name == sitemap.ROBOTS {
+		body, err := web.Robots()
+		if err != nil {
+			log.Error(err)
+			return true, ctx.SendStatus(fiber.StatusInternalServerError)
+		}
+		ctx.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+		return true, ctx.Send(body)
+	}
+
+	if !sitemap.IsFile(name) {
+		return false, nil
+	}
+	files, err := web.Sitemaps()
+	if err != nil {
+		log.Error(err)
+		return true, ctx.SendStatus(fiber.StatusInternalServerError)
+	}
+	body, ok := files[name]
+	if !ok {
+		return false, nil
+	}
+	ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationXMLCharsetUTF8)
+	return true, ctx.Send(body)
+}
@@ -0,0 +1,90 @@
+package web
+
+import (
+	"fmt"
+	"julien/form"
+	"julien/spam"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// SPAM_KEY is the FormData error key of submissions failing a spam defense
+const SPAM_KEY string = "spam"
+
+// SPAM_PATH is the admin route of the rejected submission counts
+const SPAM_PATH string = "/_julien/spam"
+
+// Spam gives templates the spam defense fields of forms
+// e.g {{ Spam.Fields("contact-us")|safe }} inside the form element
+type Spam struct {
+	web *Web
+}
+
+// Fields returns the hidden fields of the spam defenses of a form
+// with a token signed now, empty for forms without defenses
+func (s Spam) Fields(name string) string {
+	fm, err := s.web.Forms().Find(name)
+	if err != nil {
+		log.Error(err)
+		return ""
+	}
+	options, err := spam.Parse(fm.Get("spam"))
+	if err != nil {
+		log.Errorf("form %s: %v", name, err)
+		return ""
+	}
+	if !options.Enabled() {
+		return ""
+	}
+	return spam.Fields(options, s.web.guard.Token(name, time.Now()))
+}
+
+// Reject counts a submission failing a spam defense and keeps it in
+// quarantine when the form asks for it
+func (web *Web) Reject(ctx *fiber.Ctx, fm *form.Form, options spam.Options, reason string, values map[string]interface{}) {
+	web.guard.Reject(fm.Name(), reason)
+	log.Warnf("form %s: rejected %s submission from %s", fm.Name(), reason, ctx.IP())
+	if !options.Quarantine {
+		return
+	}
+	quarantined := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		quarantined[key] = value
+	}
+	quarantined = IncludeData(ctx, *fm, quarantined)
+	if _, err := fm.Quarantine(reason, quarantined, ""); err != nil {
+		log.Error(err)
+	}
+}
+
+// unsigned warns about forms with signed spam defenses when no secret
+// is configured, the tokens of a random secret stop working on every
+// restart and are not shared between instances
+func unsigned(secret string, forms []*form.Form) {
+	if secret != "" {
+		return
+	}
+	for _, fm := range forms {
+		options, err := spam.Parse(fm.Get("spam"))
+		if err != nil {
+			log.Errorf("form %s: %v", fm.Name(), err)
+			continue
+		}
+		if options.Signed() {
+			log.Warnf("form %s: spam defenses are signed with a random secret, set secret in the config", fm.Name())
+		}
+	}
+}
+
+// field returns a posted value of a json or form body
+func field(ctx *fiber.Ctx, data map[string]interface{}, key string) string {
+	if key == "" {
+		return ""
+	}
+	if value, ok := data[key]; ok && value != nil {
+		return fmt.Sprint(value)
+	}
+	return ctx.FormValue(key)
+}
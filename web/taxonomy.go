@@ -0,0 +1,121 @@
+package web
+
+import (
+	"fmt"
+	iofs "io/fs"
+	"julien/pager"
+	"sort"
+	"strings"
+)
+
+// Taxonomies gives templates the taxonomies of the config
+// e.g Taxonomies.Get("tags").Terms()
+type Taxonomies struct {
+	web *Web
+}
+
+// Get returns the taxonomy of a name or nil if not configured
+func (t Taxonomies) Get(name string) *pager.Taxonomy {
+	taxonomy, err := t.web.Taxonomy(name)
+	if err != nil {
+		return nil
+	}
+	return taxonomy
+}
+
+// Names returns the names of the configured taxonomies
+func (t Taxonomies) Names() []string {
+	names := make([]string, 0, len(t.web.config.Taxonomies))
+	for name := range t.web.config.Taxonomies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Taxonomy returns the terms of a configured taxonomy
+func (web *Web) Taxonomy(name string) (*pager.Taxonomy, error) {
+	config, ok := web.config.Taxonomy(name)
+	if !ok {
+		return nil, fmt.Errorf("taxonomy not found: %s", name)
+	}
+	return web.Content().Taxonomy(name, config.Key)
+}
+
+// TaxonomyPage returns the page listing the terms of a taxonomy
+// for /<taxonomy> or the pages of a term for /<taxonomy>/<term>
+func (web *Web) TaxonomyPage(ppath string) (*pager.Page, error) {
+	parts := strings.Split(strings.Trim(ppath, "/"), "/")
+	config, ok := web.config.Taxonomy(parts[0])
+	if !ok || len(parts) > 2 {
+		return nil, iofs.ErrNotExist
+	}
+	taxonomy, err := web.Taxonomy(parts[0])
+	if err != nil {
+		return nil, err
+	}
+
+	layout := config.Layout
+	if layout == "" {
+		if home, err := web.Content().Find("/"); err == nil {
+			layout = home.Layout()
+		}
+	}
+
+	meta := map[string]interface{}{
+		"title":    taxonomy.Name(),
+		"view":     config.Terms,
+		"layout":   layout,
+		"taxonomy": taxonomy.Name(),
+	}
+	if len(parts) == 2 {
+		term := taxonomy.Term(parts[1])
+		if term == nil || term.Slug() != parts[1] {
+			return nil, iofs.ErrNotExist
+		}
+		meta["title"] = term.Name()
+		meta["view"] = config.View
+		meta["term"] = term.Slug()
+	}
+	return web.Content().Virtual(strings.Join(parts, "/"), meta)
+}
+
+// TaxonomyPages returns the terms page and
+// the term pages of every configured taxonomy
+func (web *Web) TaxonomyPages() ([]*pager.Page, error) {
+	pages := make([]*pager.Page, 0)
+	for _, name := range (Taxonomies{web: web}).Names() {
+		taxonomy, err := web.Taxonomy(name)
+		if err != nil {
+			return nil, err
+		}
+		paths := []string{name}
+		for _, term := range taxonomy.Terms() {
+			paths = append(paths, name+"/"+term.Slug())
+		}
+		for _, ppath := range paths {
+			page, err := web.TaxonomyPage(ppath)
+			if err != nil {
+				return nil, err
+			}
+			pages = append(pages, page)
+		}
+	}
+	return pages, nil
+}
+
+// taxonomyParams adds the taxonomy and term listed by a taxonomy page
+func (web *Web) taxonomyParams(page *pager.Page, vparams map[string]interface{}) {
+	name, ok := page.Get("taxonomy").(string)
+	if !ok {
+		return
+	}
+	taxonomy, err := web.Taxonomy(name)
+	if err != nil {
+		return
+	}
+	vparams["Taxonomy"] = taxonomy
+	if term, ok := page.Get("term").(string); ok {
+		vparams["Term"] = taxonomy.Term(term)
+	}
+}
@@ -1,20 +1,32 @@
 package web
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	iofs "io/fs"
+	"julien/contract"
 	"julien/driver"
 	"julien/form"
 	"julien/fs"
 	"julien/julien"
+	"julien/limit"
+	"julien/mail"
+	"julien/markdown"
 	"julien/pager"
+	"julien/spam"
 	"julien/template"
 	jutils "julien/utils"
+	"julien/webhook"
+	"net/http"
 	"net/url"
 	"os"
 	"path"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -23,6 +35,7 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/compress"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/csrf"
+	"github.com/gofiber/fiber/v2/middleware/filesystem"
 	"github.com/gofiber/fiber/v2/middleware/idempotency"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/monitor"
@@ -60,6 +73,32 @@ type Web struct {
 	forms    *form.Root
 	content  *pager.Root
 	template *template.Template
+	bundle   iofs.FS
+	assets   fs.Storage // Storage of the content mount assets
+	views    fiber.Views
+	watcher  *fs.Watcher // Invalidates the indexed pages and forms
+	dev      *Dev
+	lock     *sync.RWMutex  // Guards reloads of a Web in dev mode
+	drafts   bool           // Show unpublished pages
+	mailer   *mail.Mailer   // Sends the form notifications, nil without smtp
+	outbox   *mail.Outbox   // Sends the mails of the mailer once started
+	queue    *webhook.Queue // Delivers the form webhooks once started
+	guard    *spam.Guard    // Checks the spam defenses of the forms
+	limiter  *limit.Limiter // Counts the submissions of the forms
+}
+
+// engine renders with the views of the current template
+// so a reloaded template replaces the views of the app
+type engine struct {
+	web *Web
+}
+
+func (e engine) Load() error {
+	return e.web.views.Load()
+}
+
+func (e engine) Render(out io.Writer, name string, binding interface{}, layout ...string) error {
+	return e.web.views.Render(out, name, binding, layout...)
 }
 
 func SaveSession(sess *session.Session) {
@@ -114,6 +153,8 @@ func MakePageInfo(fm *form.Form) map[string]string {
 		"timestamp":  ts,
 		"default":    ts,
 		"name":       fm.Name(),
+		"uuid":       utils.UUIDv4(),
+		"date":       now.Format("2006-01-02"),
 	}
 }
 
@@ -127,7 +168,9 @@ func GetName(format string, info map[string]string, data map[string]interface{})
 	} else if format[0] == '@' {
 		value, ok := data[format[1:]].(string)
 		if ok {
-			return value
+			// Submitted values must not
+			// climb out of the form data
+			return fs.CleanName(value)
 		}
 		return "data[" + format + "]"
 	} else {
@@ -136,21 +179,30 @@ func GetName(format string, info map[string]string, data map[string]interface{})
 
 }
 
-func MakeName(fm *form.Form, data map[string]interface{}) string {
+// MakeName makes the name of a form submission from the name key of
+// the form, names numbered with $seq fail on storages without counters
+func MakeName(fm *form.Form, data map[string]interface{}) (string, error) {
 	format, ok := fm.Get("name").(string)
 	if !ok {
 		format = "$default"
 	}
 
-	seperator, ok := fm.Get("seperator").(string)
-	if !ok {
-		seperator = "_"
-	}
+	seperator := fm.Seperator()
 
 	parts := strings.Split(format, seperator)
 
 	info := MakePageInfo(fm)
 	for index, part := range parts {
+		// Only count submissions of forms numbering them
+		if part == "$seq" {
+			if _, ok := info["seq"]; !ok {
+				seq, err := fm.Next()
+				if err != nil {
+					return "", err
+				}
+				info["seq"] = strconv.FormatInt(seq, 10)
+			}
+		}
 		parts[index] = GetName(part, info, data)
 	}
 
@@ -161,7 +213,30 @@ func MakeName(fm *form.Form, data map[string]interface{}) string {
 		}
 	}
 
-	return strings.Join(cleaned, seperator)
+	name := strings.Join(cleaned, seperator)
+	if name == "" {
+		return info["default"], nil
+	}
+	return name, nil
+}
+
+// NameFields returns the submitted fields a form submission name is
+// made from, or the form name key if the name has no @field parts
+func NameFields(fm *form.Form) []string {
+	format, ok := fm.Get("name").(string)
+	if !ok {
+		format = "$default"
+	}
+	fields := make([]string, 0)
+	for _, part := range strings.Split(format, fm.Seperator()) {
+		if len(part) > 1 && part[0] == '@' {
+			fields = append(fields, part[1:])
+		}
+	}
+	if len(fields) == 0 {
+		fields = append(fields, "name")
+	}
+	return fields
 }
 
 func collect(data map[string]interface{}, rules map[string]interface{}) map[string]interface{} {
@@ -181,6 +256,28 @@ func render(web *Web, ctx *fiber.Ctx, name string) error {
 	content := web.Content()
 	code, cerr := strconv.Atoi(name)
 	page, err := content.Find(name)
+
+	// Numbered pages of a paginated page e.g articles/page/2
+	ppath, number, numbered := name, ctx.QueryInt(pager.PAGE_PATH, 1), false
+	if err != nil {
+		if pname, pnumber, ok := pager.SplitPageNumber(name); ok {
+			page, err = content.Find(pname)
+			ppath, number, numbered = pname, pnumber, true
+		}
+	}
+	if err != nil {
+		page, err = web.TaxonomyPage(ppath)
+	}
+
+	// Unpublished pages are not found unless previewed
+	if err == nil && cerr != nil && !content.Visible(page) {
+		if web.Previewing(ctx, page) {
+			ctx.Set(fiber.HeaderCacheControl, "no-store")
+			ctx.Set("X-Robots-Tag", "noindex")
+		} else {
+			err = iofs.ErrNotExist
+		}
+	}
 	if err != nil {
 		if cerr == nil && code == 400 {
 			return ctx.SendStatus(code)
@@ -188,7 +285,7 @@ func render(web *Web, ctx *fiber.Ctx, name string) error {
 			return render(web, ctx, "404")
 		}
 	} else {
-		if cerr == nil && (code < 400 || code > 451) && (code < 500 || code > 511) {
+		if cerr != nil || (code < 400 || code > 451) && (code < 500 || code > 511) {
 			code = fiber.StatusOK
 		}
 	}
@@ -198,8 +295,7 @@ func render(web *Web, ctx *fiber.Ctx, name string) error {
 		return ctx.Redirect("/"+page.Path(), 302)
 	}
 
-	view := page.View()
-	layout := page.Layout()
+	view, layout := web.ViewPaths(page)
 
 	// Get session from storage
 	sess, err := web.Session(ctx)
@@ -220,15 +316,11 @@ func render(web *Web, ctx *fiber.Ctx, name string) error {
 		log.Error(err)
 	}
 
-	vparams := fiber.Map{
-		"Ctx":      ctx,
-		"Page":     page,
-		"Site":     web.Site(),
-		"Forms":    forms,
-		"Pager":    content,
-		"FormData": formdata,
-		"Template": web.Template(),
-	}
+	vparams := web.ViewParams(page)
+	vparams["Ctx"] = ctx
+	vparams["FormData"] = formdata
+	pagination := NewPagination(page, number)
+	vparams["Paginate"] = pagination.Paginate
 
 	postedstr, ok := sess.Get(POST_KEY).(string)
 	if !ok {
@@ -255,21 +347,92 @@ func render(web *Web, ctx *fiber.Ctx, name string) error {
 		}
 	}
 
-	// Done extracting valid form data from session
-	// So we don't need this in the session
-	// anymore so trash it
-	sess.Delete(FORM_KEY)
-	sess.Delete(POST_KEY)
-	SaveSession(sess)
+	html := bytes.NewBuffer(nil)
+	if err := web.views.Render(html, view, vparams, layout); err != nil {
+		if web.dev != nil {
+			return web.Overlay(ctx, err)
+		}
+		return err
+	}
+
+	// Page numbers past the paginated collections are not found
+	// instead of repeating the last page, the page number of pages
+	// that paginate nothing is ignored unless in their path
+	if cerr != nil && !pagination.Exists(numbered) {
+		return render(web, ctx, "404")
+	}
 
-	view = path.Clean(path.Join(web.template.GetString(VIEWS_KEY, VIEWS_KEY), view))
-	layout = path.Clean(path.Join(web.template.GetString(LAYOUTS_KEY, LAYOUTS_KEY), layout))
+	// The page is served so the posted form data it
+	// showed is not needed in the session anymore
+	if cerr != nil {
+		sess.Delete(FORM_KEY)
+		sess.Delete(POST_KEY)
+		SaveSession(sess)
+	}
 
-	if (code < 400 || code > 451) && (code < 500 || code > 511) {
-		return ctx.Render(view, vparams, layout)
-	} else {
-		return ctx.Status(code).Render(view, vparams, layout)
+	ctx.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return ctx.Status(code).Send(html.Bytes())
+}
+
+// Pagination binds the paginators of a rendered page to the
+// requested page number and records how many pages they have
+type Pagination struct {
+	page      *pager.Page
+	number    int
+	total     int
+	paginated bool // Whether a collection was paginated
+}
+
+func NewPagination(page *pager.Page, number int) *Pagination {
+	return &Pagination{page: page, number: number, total: 1}
+}
+
+// Paginate paginates a collection for the requested page number,
+// templates call it as Paginate(Page.Collection(), 10)
+func (p *Pagination) Paginate(collection *pager.Collection, size int) *pager.Paginator {
+	paginator := collection.Paginate(size, p.number, p.page.APath())
+	p.total = max(p.total, paginator.Total())
+	p.paginated = true
+	return paginator
+}
+
+// Total returns the most pages of the paginated collections
+func (p *Pagination) Total() int {
+	return p.total
+}
+
+// Exists reports whether the requested page number is a page of the
+// paginated collections, numbered paths e.g about/page/1 only exist
+// for pages paginating a collection, other pages have every number
+func (p *Pagination) Exists(numbered bool) bool {
+	if !p.paginated {
+		return !numbered
 	}
+	return p.number >= 1 && p.number <= p.total
+}
+
+// ViewParams returns the variables every page view is rendered with
+func (web *Web) ViewParams(page *pager.Page) fiber.Map {
+	vparams := fiber.Map{
+		"Page":       page,
+		"Site":       web.Site(),
+		"Forms":      web.Forms(),
+		"Pager":      web.Content(),
+		"FormData":   &FormData{},
+		"Template":   web.Template(),
+		"Taxonomies": Taxonomies{web: web},
+		"Spam":       Spam{web: web},
+		"Feeds":      web.FeedLinks(page),
+	}
+	web.taxonomyParams(page, vparams)
+	return vparams
+}
+
+// ViewPaths returns the template view and layout a page is rendered with
+func (web *Web) ViewPaths(page *pager.Page) (string, string) {
+	view := path.Clean(path.Join(web.template.GetString(VIEWS_KEY, VIEWS_KEY), page.View()))
+	layout := path.Clean(path.Join(web.template.GetString(LAYOUTS_KEY, LAYOUTS_KEY), page.Layout()))
+	return view, layout
 }
 
 func mapcast(schema map[interface{}]interface{}) (map[string]interface{}, error) {
@@ -288,25 +451,119 @@ func BaseUrl(link *url.URL) string {
 	return link.Scheme + "://" + link.Host + link.Path
 }
 
+func MountDriver(mount string, name string) contract.Driver {
+	drv, err := driver.Find(name)
+	if err != nil {
+		err = fmt.Errorf("%s mount: %w", mount, err)
+		log.Error(err)
+		panic(err)
+	}
+	return drv
+}
+
+func MountStorage(mount string, mp julien.MountPoint) fs.Storage {
+	disk, err := mp.Mount()
+	if err != nil {
+		err = fmt.Errorf("%s mount: %w", mount, err)
+		log.Error(err)
+		panic(err)
+	}
+	if mount == "data" && mp.Storage == "memory" {
+		log.Warnf("data mount: memory storage, form submissions are lost when julien stops")
+	}
+	return disk
+}
+
+// MountBundle mounts a read only mount point of a site bundle
+func MountBundle(mount string, bundle iofs.FS, mp julien.MountPoint) fs.Storage {
+	disk, err := fs.MountFS(bundle, mp.Path, mp.Index, mp.Ext)
+	if err != nil {
+		err = fmt.Errorf("%s mount: %w", mount, err)
+		log.Error(err)
+		panic(err)
+	}
+	return disk
+}
+
+// LoadTemplate loads the configured template with its
+// overrides and parents from disk or from a site bundle
+func LoadTemplate(config *julien.Julien, bundle iofs.FS) *template.Template {
+	var err error
+	var root iofs.FS
+	if bundle != nil {
+		root, err = iofs.Sub(bundle, path.Clean(config.Template.Path))
+	} else {
+		root = os.DirFS(config.Template.Path)
+	}
+	if err == nil {
+		var tmpl *template.Template
+		tmpl, err = template.Load(root, config.Template.Path, config.TemplateName(), config.TemplateOverrides()...)
+		if err == nil {
+			return tmpl
+		}
+	}
+	log.Error(err)
+	panic(err)
+}
+
 func New(config *julien.Julien, site *julien.Site) Web {
+	return NewFS(config, site, nil)
+}
+
+// NewFS creates a Web serving the content, forms, template and
+// static mounts from a read only site bundle, the data mount
+// stays on disk. A nil bundle serves every mount from disk.
+func NewFS(config *julien.Julien, site *julien.Site, bundle iofs.FS) Web {
 	store := session.New()
-	yamler := &driver.Yaml{}
 
 	Data := config.Data
 	Forms := config.Forms
 	Content := config.Content
 
-	tmpl, err := template.Find(config.TemplatePath())
+	var cdisk, fdisk fs.Storage
+	if bundle != nil {
+		cdisk = MountBundle("content", bundle, Content)
+		fdisk = MountBundle("forms", bundle, Forms)
+	} else {
+		cdisk = MountStorage("content", Content)
+		fdisk = MountStorage("forms", Forms)
+	}
+
+	tmpl := LoadTemplate(config, bundle)
+
+	ddisk := MountStorage("data", Data)
+	forms := form.Init(fdisk, ddisk, MountDriver("forms", Forms.Driver), MountDriver("data", Data.Driver))
+	content := pager.Init(cdisk, MountDriver("content", Content.Driver))
+
+	// Pages and the markdown filter render alike
+	md := markdown.New(config.Markdown)
+	content.UseMarkdown(md)
+	template.UseMarkdown(md)
+
+	// Fail early on forms with an unknown driver
+	// instead of on their first submission
+	list, err := forms.List()
 	if err != nil {
 		log.Error(err)
 		panic(err)
 	}
-
-	cdisk := fs.Mount(Content.Path, Content.Index, Content.Ext)
-	ddisk := fs.Mount(Data.Path, Data.Index, Data.Ext)
-	fdisk := fs.Mount(Forms.Path, Forms.Index, Forms.Ext)
-	forms := form.Init(fdisk, ddisk, yamler)
-	content := pager.Init(cdisk, yamler)
+	unsigned(config.Secret, list)
+	if _, err := config.BodySize(); err != nil {
+		log.Error(err)
+		panic(err)
+	}
+	if _, err := config.RateLimit.Rule(); err != nil {
+		log.Error(err)
+		panic(err)
+	}
+	var mailer *mail.Mailer
+	if config.SMTP.Host != "" {
+		var err error
+		if mailer, err = mail.New(config.SMTP); err != nil {
+			log.Error(err)
+			panic(err)
+		}
+	}
 	return Web{
 		config:   config,
 		store:    store,
@@ -314,6 +571,12 @@ func New(config *julien.Julien, site *julien.Site) Web {
 		content:  &content,
 		site:     site,
 		template: tmpl,
+		bundle:   bundle,
+		assets:   cdisk,
+		mailer:   mailer,
+		guard:    spam.NewGuard(config.Secret),
+		limiter:  limit.New(ddisk),
+		lock:     &sync.RWMutex{},
 	}
 }
 
@@ -376,16 +639,38 @@ func (web *Web) StaticPath() string {
 	return web.config.StaticPath()
 }
 
+// Static returns the static files from disk or from the site bundle
+func (web *Web) Static() (iofs.FS, error) {
+	if web.bundle != nil {
+		return iofs.Sub(web.bundle, path.Clean(web.StaticPath()))
+	}
+	return os.DirFS(web.StaticPath()), nil
+}
+
 func (web *Web) Start(addr string) {
 
+	web.index()
+	web.queue = webhook.NewQueue(web.forms.Data(), web.Delivered)
+	web.queue.Start()
+	web.outbox = outgoing(web.mailer)
+	web.views = web.template.Engine(true)
+	limit, _ := web.config.BodySize()
+	proxy := web.config.Proxy
 	var app = fiber.New(fiber.Config{
-		AppName: "Julien",
-		Views:   web.template.Engine(true),
+		AppName:   "Julien",
+		Views:     engine{web: web},
+		BodyLimit: limit,
+		// Client ips of requests through a reverse proxy
+		ProxyHeader:             proxy.Header,
+		EnableIPValidation:      proxy.Header != "",
+		EnableTrustedProxyCheck: len(proxy.Trusted) > 0,
+		TrustedProxies:          proxy.Trusted,
 	})
 
 	app.Use(idempotency.New())
 	app.Use(cors.New())
 	app.Use(csrf.New(csrf.Config{
+		Next:              web.TrustedOrigin,
 		KeyLookup:         "cookie:csrf",
 		CookieName:        "csrf",
 		CookieSameSite:    "Lax",
@@ -397,29 +682,139 @@ func (web *Web) Start(addr string) {
 	}))
 
 	app.Use(web.Logger())
-	app.Use(compress.New())
+	app.Use(compress.New(compress.Config{
+		// Events must reach open pages unbuffered
+		Next: func(c *fiber.Ctx) bool {
+			return web.dev != nil && c.Path() == RELOAD_PATH
+		},
+	}))
 
-	app.Static("/static", web.config.StaticPath(), fiber.Static{
-		Compress:      true,
-		ByteRange:     true,
-		CacheDuration: 24 * 60 * 60 * time.Second,
-	})
+	if web.dev != nil {
+		app.Use(web.inject)
+		app.Get(RELOAD_PATH, web.Events)
+	}
 
-	app.Static("/public", web.TemplatePath()+"/public")
+	if web.bundle != nil {
+		web.bundled(app)
+	} else {
+		app.Static("/static", web.config.StaticPath(), fiber.Static{
+			Compress:      true,
+			ByteRange:     true,
+			CacheDuration: 24 * 60 * 60 * time.Second,
+		})
+	}
+
+	// Public files of the template, its overrides and parents
+	app.Get("/public/*", func(c *fiber.Ctx) error {
+		web.lock.RLock()
+		defer web.lock.RUnlock()
+		return web.SendPublic(c)
+	})
 
 	app.Get("/metrics", monitor.New())
 
+	// Files of form submissions for the admin users
+	app.Get(FILES_PATH+"/*", web.Admin(), func(c *fiber.Ctx) error {
+		web.lock.RLock()
+		defer web.lock.RUnlock()
+		return web.SendFile(c)
+	})
+
+	// Rejected submissions by form and reason
+	app.Get(SPAM_PATH, web.Admin(), func(c *fiber.Ctx) error {
+		return c.JSON(web.guard.Counts())
+	})
+
 	app.Get("/*", func(c *fiber.Ctx) error {
+		web.lock.RLock()
+		defer web.lock.RUnlock()
 		return web.RenderPage(c)
 	})
 
 	app.Post("/:form?", func(c *fiber.Ctx) error {
+		web.lock.RLock()
+		defer web.lock.RUnlock()
 		return web.RenderForm(c)
 	})
 
 	app.Listen(addr)
 }
 
+// index parses the pages and forms into memory and watches
+// the content and forms mounts to drop them on change. Only
+// disk mounts are watched, the others are read on each request.
+// Bundled sites are read only and stay indexed
+func (web *Web) index() {
+	if web.watcher != nil {
+		web.watcher.Close()
+		web.watcher = nil
+	}
+
+	bundled := web.bundle != nil
+	if bundled || watched(web.config.Content) {
+		if err := web.content.Index(); err != nil {
+			log.Error(err)
+		}
+	}
+	if bundled || watched(web.config.Forms) {
+		if err := web.forms.Index(); err != nil {
+			log.Error(err)
+		}
+	}
+	if bundled {
+		return
+	}
+
+	paths := make([]string, 0)
+	for _, mp := range []julien.MountPoint{web.config.Content, web.config.Forms} {
+		if watched(mp) {
+			paths = append(paths, mp.Path)
+		}
+	}
+	if len(paths) == 0 {
+		return
+	}
+	content, forms := web.content, web.forms
+	watcher, err := fs.Watch(RELOAD_DELAY, func(changed []string, err error) {
+		if err != nil {
+			log.Error(err)
+			return
+		}
+		content.Invalidate()
+		forms.Invalidate()
+	}, paths...)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	web.watcher = watcher
+}
+
+// watched reports whether changes to a mount are seen by a watcher
+func watched(mp julien.MountPoint) bool {
+	return mp.Storage == "" || mp.Storage == "disk"
+}
+
+// bundled serves the static files of a site bundle
+func (web *Web) bundled(app *fiber.App) {
+	if static, err := iofs.Sub(web.bundle, path.Clean(web.StaticPath())); err == nil {
+		app.Use("/static", filesystem.New(filesystem.Config{
+			Root:   http.FS(static),
+			MaxAge: 24 * 60 * 60,
+		}))
+	}
+}
+
+// TrustedOrigin reports whether a request comes from one of
+// the configured origins, e.g pages exported with julien build
+// posting their forms, which cannot carry a csrf token
+func (web *Web) TrustedOrigin(ctx *fiber.Ctx) bool {
+	web.lock.RLock()
+	defer web.lock.RUnlock()
+	origin := ctx.Get(fiber.HeaderOrigin)
+	return origin != "" && jutils.ArrayIncludes(web.config.Origins, origin)
+}
+
 func (web *Web) Forms() *form.Root {
 	return web.forms
 }
@@ -429,16 +824,85 @@ func (web *Web) Content() *pager.Root {
 }
 
 func (web *Web) RenderPage(ctx *fiber.Ctx) error {
+	// Show why the site or config failed to reload
+	if web.dev != nil && web.dev.err != nil {
+		return web.Overlay(ctx, web.dev.err)
+	}
+
 	name := ctx.Params("*")
 	ext := path.Ext(name)
 
+	// Feeds of sections e.g articles/feed.xml
+	if sent, err := web.SendFeed(ctx, name); sent {
+		return err
+	}
+
+	// Sitemaps and robots.txt of the site
+	if sent, err := web.SendSitemap(ctx, name); sent {
+		return err
+	}
+
 	if ext != "" && jutils.ArrayIncludes(web.AllowedFiles(), ext[1:]) {
-		filepath := path.Join(web.ContentPath(), ctx.Path())
-		return ctx.SendFile(filepath, true)
+		return web.SendAsset(ctx, name)
 	}
 	return render(web, ctx, name)
 }
 
+// SendAsset sends a file of the content mount, files resolving
+// outside of the mount are not found
+func (web *Web) SendAsset(ctx *fiber.Ctx, name string) error {
+	name, err := url.PathUnescape(name)
+	if err != nil {
+		return ctx.SendStatus(fiber.StatusNotFound)
+	}
+	name = path.Clean("/" + name)
+
+	// Disk mounts may hold symlinks leading anywhere
+	if disk, ok := web.assets.(*fs.Disk); ok {
+		if _, err := disk.Resolve(name); err != nil {
+			log.Error(err)
+			return ctx.SendStatus(fiber.StatusNotFound)
+		}
+	}
+
+	disk, ok := web.assets.(interface{ FS() iofs.FS })
+	if !ok {
+		return ctx.SendStatus(fiber.StatusNotFound)
+	}
+	return filesystem.SendFile(ctx, http.FS(disk.FS()), name)
+}
+
+// SendPublic sends a public file of the template, its overrides and parents
+func (web *Web) SendPublic(ctx *fiber.Ctx) error {
+	public, err := web.template.Public()
+	if err != nil {
+		return ctx.SendStatus(fiber.StatusNotFound)
+	}
+	name, err := url.PathUnescape(ctx.Params("*"))
+	if err != nil {
+		return ctx.SendStatus(fiber.StatusNotFound)
+	}
+	return filesystem.SendFile(ctx, http.FS(public), path.Clean("/"+name))
+}
+
+// refuse answers a submission with errors, form posts are redirected
+// back with the errors in FormData and json posts get the errors
+func (web *Web) refuse(ctx *fiber.Ctx, sess *session.Session, fm *form.Form, values map[string]interface{}, errormap map[string][]string, is_formdata bool, status int) error {
+	if is_formdata {
+		formdata := FormData{
+			Name:      fm.Name(),
+			Data:      values,
+			Errors:    errormap,
+			Timestamp: time.Now().Unix(),
+		}
+		serialdata, _ := json.Marshal(formdata)
+		sess.Set(FORM_KEY, string(serialdata))
+		SaveSession(sess)
+		return ctx.Redirect(ctx.Get("Referer", "/"), 302)
+	}
+	return ctx.Status(status).JSON(errormap)
+}
+
 func (web *Web) RenderForm(ctx *fiber.Ctx) error {
 	var is_formdata = false
 	var errormap = make(map[string][]string, 0)
@@ -486,15 +950,59 @@ func (web *Web) RenderForm(ctx *fiber.Ctx) error {
 		}
 	}
 
+	fields, err := fm.FileFields()
+	if err != nil {
+		log.Error(err)
+		return render(web, ctx, "500")
+	}
+	files, err := uploads(ctx, fields)
+	if err != nil {
+		log.Error(err)
+		return ctx.Redirect(source, 302)
+	}
+	if len(files) > 0 {
+		is_formdata = true
+	}
+
 	values := collect(data, skrules)
 
 	// Redirect if no value was submitted
-	if len(values) == 0 {
+	if len(values) == 0 && len(files) == 0 {
 		return ctx.Redirect(source, 302)
 	}
 
-	errors := validate.ValidateMap(values, skrules)
-	for key, ferror := range errors {
+	// Bots rarely pass validation so the defenses run first
+	// for their posts to be counted and quarantined
+	options, err := spam.Parse(fm.Get("spam"))
+	if err != nil {
+		log.Errorf("form %s: %v", name, err)
+		return render(web, ctx, "500")
+	}
+	submission := spam.Submission{
+		Honeypot: field(ctx, data, options.Honeypot),
+		Token:    field(ctx, data, spam.TOKEN_FIELD),
+		Work:     field(ctx, data, spam.WORK_FIELD),
+	}
+	if reason := web.guard.Check(name, options, submission, time.Now()); reason != "" {
+		web.Reject(ctx, fm, options, reason, values)
+		if reason == spam.HONEYPOT {
+			// Bots filling the honeypot are answered as if
+			// their submission was taken
+			repath, ok := fm.Get("redirect").(string)
+			if !ok {
+				repath = name
+			}
+			if is_formdata {
+				return ctx.Redirect(repath, 302)
+			}
+			return ctx.JSON(make(map[string]string, 0))
+		}
+		errormap[SPAM_KEY] = []string{reason}
+		return web.refuse(ctx, sess, fm, values, errormap, is_formdata, fiber.StatusForbidden)
+	}
+
+	verrors := validate.ValidateMap(values, skrules)
+	for key, ferror := range verrors {
 		ferrmap := make([]string, 0)
 		ferrors := ferror.(validator.ValidationErrors)
 		for _, ferr := range ferrors {
@@ -502,32 +1010,33 @@ func (web *Web) RenderForm(ctx *fiber.Ctx) error {
 		}
 		errormap[key] = ferrmap
 	}
-
-	if len(errors) > 0 {
-		if is_formdata {
-			formdata := FormData{
-				Name:      name,
-				Data:      values,
-				Errors:    errormap,
-				Timestamp: time.Now().Unix(),
-			}
-			serialdata, _ := json.Marshal(formdata)
-			sess.Set(FORM_KEY, string(serialdata))
-			SaveSession(sess)
-			return ctx.Redirect(source, 302)
+	for name, field := range fields {
+		if ferrmap := field.Check(files[name]); len(ferrmap) > 0 {
+			errormap[name] = append(errormap[name], ferrmap...)
 		}
-		return ctx.JSON(errormap)
 	}
 
-	values = IncludeData(ctx, *fm, values)
-	filename := MakeName(fm, values)
+	if len(errormap) > 0 {
+		return web.refuse(ctx, sess, fm, values, errormap, is_formdata, fiber.StatusOK)
+	}
 
-	// Create new doc for form data
-	// return 500 if this fails
-	doc, err := fm.Compose(filename, make([]byte, 0))
+	wait, refund, err := web.Limited(ctx, fm, sess, data)
 	if err != nil {
 		log.Error(err)
-		return ctx.Redirect(source, 500)
+		return render(web, ctx, "500")
+	}
+	if wait > 0 {
+		ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(wait.Seconds())))
+		errormap[LIMIT_KEY] = []string{"rate_limit"}
+		return web.refuse(ctx, sess, fm, values, errormap, is_formdata, fiber.StatusTooManyRequests)
+	}
+
+	values = IncludeData(ctx, *fm, values)
+	filename, err := MakeName(fm, values)
+	if err != nil {
+		log.Error(err)
+		refund()
+		return render(web, ctx, "500")
 	}
 
 	content := ""
@@ -544,20 +1053,37 @@ func (web *Web) RenderForm(ctx *fiber.Ctx) error {
 		}
 	}
 
-	// Fill created doc with form data and save
+	// Store form data as a new doc
 	// return 500 if this fails
-	doc.Fill(values)
-	doc.Body(content)
-	err = doc.Save()
+	doc, err := fm.Submit(filename, values, content)
+	if err != nil {
+		// Only stored submissions count against the rate limit
+		refund()
+	}
+	if errors.Is(err, iofs.ErrExist) {
+		// Name taken and the form collision
+		// strategy is to fail, flag the fields
+		// the submission name is made from
+		for _, field := range NameFields(fm) {
+			errormap[field] = []string{"exists"}
+		}
+		return web.refuse(ctx, sess, fm, values, errormap, is_formdata, fiber.StatusConflict)
+	}
 	if err != nil {
 		log.Error(err)
 		return ctx.Redirect(source, 500)
 	}
+	if err := attach(doc, files); err != nil {
+		log.Error(err)
+		return ctx.Redirect(source, 500)
+	}
+	web.Notify(fm, doc)
+	web.Hook(fm, doc)
 
 	// Record form subimission in session
 	posted := Posted{
 		Form:      name,
-		Doc:       filename,
+		Doc:       doc.Name(),
 		Timestamp: time.Now().Unix(),
 	}
 	serialdata, _ := json.Marshal(posted)
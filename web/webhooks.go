@@ -0,0 +1,142 @@
+package web
+
+import (
+	"bytes"
+	"fmt"
+	"julien/form"
+	"julien/webhook"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// WEBHOOKS_KEY is the frontmatter key of the delivery statuses of a submission
+const WEBHOOKS_KEY string = "webhooks"
+
+// Hook queues the webhooks of the form of a submission, their
+// statuses are recorded on the submission as they are delivered
+func (web *Web) Hook(fm *form.Form, doc *form.Doc) {
+	if web.queue == nil {
+		return
+	}
+	hooks, err := fm.Webhooks()
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+
+	post := Post{Form: fm, Data: doc, Timestamp: time.Now().Unix()}
+	statuses := deliveries(doc)
+	queued := make([]*webhook.Delivery, 0, len(hooks))
+	for name, hook := range hooks {
+		body, err := web.payload(hook, post)
+		if err != nil {
+			log.Errorf("form %s: webhook %s: %v", fm.Name(), name, err)
+			continue
+		}
+		headers := map[string]string{fiber.HeaderContentType: fiber.MIMEApplicationJSON}
+		for key, value := range hook.Headers {
+			headers[key] = value
+		}
+		delivery := webhook.New(hook.Method, hook.URL, headers, body, hook.Secret)
+		delivery.Form, delivery.Doc, delivery.Hook = fm.Name(), doc.Name(), name
+		statuses[name] = status(delivery)
+		queued = append(queued, delivery)
+	}
+
+	// Pending statuses are saved first so a delivery
+	// reported right away is not overwritten
+	doc.Set(WEBHOOKS_KEY, statuses)
+	if err := doc.Save(); err != nil {
+		log.Error(err)
+	}
+	for _, delivery := range queued {
+		if err := web.queue.Enqueue(delivery); err != nil {
+			log.Errorf("form %s: webhook %s: %v", fm.Name(), delivery.Hook, err)
+		}
+	}
+}
+
+// Delivered records the status of a delivery attempt on its submission
+func (web *Web) Delivered(delivery *webhook.Delivery) {
+	web.lock.RLock()
+	defer web.lock.RUnlock()
+	if delivery.Status == webhook.FAILED {
+		log.Errorf("form %s: %s: webhook %s failed: %s", delivery.Form, delivery.Doc, delivery.Hook, delivery.Error)
+	}
+	fm, err := web.Forms().Find(delivery.Form)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	doc, err := fm.Find(delivery.Doc)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	statuses := deliveries(doc)
+	statuses[delivery.Hook] = status(delivery)
+	doc.Set(WEBHOOKS_KEY, statuses)
+	if err := doc.Save(); err != nil {
+		log.Error(err)
+	}
+}
+
+// payload renders the body template of a webhook with the Post
+// of the submission, or the submission as json without a template
+func (web *Web) payload(hook form.Webhook, post Post) ([]byte, error) {
+	if hook.Template == "" {
+		at := time.Unix(post.Timestamp, 0)
+		return webhook.Payload(post.Form.Name(), post.Data.Name(), post.Data.Frontmatter(), post.Data.Body(), at)
+	}
+	out := bytes.NewBuffer(nil)
+	binding := fiber.Map{
+		"Post":  post,
+		"Site":  web.Site(),
+		"Forms": web.Forms(),
+	}
+	if err := web.views.Render(out, hook.Template, binding); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// deliveries returns the recorded delivery statuses of a submission by webhook
+func deliveries(doc *form.Doc) map[string]interface{} {
+	statuses := make(map[string]interface{})
+	switch recorded := doc.Get(WEBHOOKS_KEY).(type) {
+	case map[interface{}]interface{}:
+		for key, value := range recorded {
+			statuses[fmt.Sprint(key)] = value
+		}
+	case map[string]interface{}:
+		for key, value := range recorded {
+			statuses[key] = value
+		}
+	}
+	return statuses
+}
+
+// status is the frontmatter record of a delivery
+func status(delivery *webhook.Delivery) map[string]interface{} {
+	record := map[string]interface{}{
+		"id":       delivery.ID,
+		"status":   delivery.Status,
+		"attempts": delivery.Attempts,
+	}
+	if delivery.Attempts > 0 {
+		record["code"] = delivery.Code
+		record["at"] = delivery.At.Format(time.RFC3339)
+	}
+	if delivery.Error != "" {
+		record["error"] = delivery.Error
+	}
+	if delivery.Status == webhook.PENDING && delivery.Attempts > 0 {
+		record["next"] = delivery.Next.Format(time.RFC3339)
+	}
+	return record
+}
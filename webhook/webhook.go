@@ -0,0 +1,443 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"julien/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// QUEUE_DIR is the directory of the pending deliveries in the data mount
+const QUEUE_DIR string = ".webhooks"
+
+// ATTEMPTS is the number of attempts before a delivery fails
+const ATTEMPTS int = 8
+
+// BACKOFF is the delay before the second attempt, doubled on each retry
+const BACKOFF time.Duration = 30 * time.Second
+
+// MAX_BACKOFF caps the delay between two attempts
+const MAX_BACKOFF time.Duration = 6 * time.Hour
+
+// TIMEOUT bounds each attempt of a delivery
+const TIMEOUT time.Duration = 10 * time.Second
+
+// WORKERS bounds the endpoints attempted at once, the deliveries
+// of an endpoint are attempted one at a time
+const WORKERS int = 4
+
+// INTERVAL is how often the queue looks for due deliveries
+const INTERVAL time.Duration = time.Second
+
+// SIGNATURE is the header of the hmac-sha256 of the body e.g sha256=<hex>
+const SIGNATURE string = "X-Julien-Signature"
+
+// DELIVERY is the header of the id of a delivery, the same on retries
+const DELIVERY string = "X-Julien-Delivery"
+
+// Statuses of a delivery
+
+const PENDING string = "pending"
+
+const DELIVERED string = "delivered"
+
+const FAILED string = "failed"
+
+// Delivery is a webhook request of a submission waiting in the queue
+type Delivery struct {
+	ID       string            `json:"id"`
+	Form     string            `json:"form"`
+	Doc      string            `json:"doc"`
+	Hook     string            `json:"hook"` // Name of the webhook of the form
+	URL      string            `json:"url"`
+	Method   string            `json:"method"`
+	Headers  map[string]string `json:"headers"`
+	Body     []byte            `json:"body"`
+	Status   string            `json:"status"`
+	Attempts int               `json:"attempts"`
+	Code     int               `json:"code"` // Response status of the last attempt
+	Error    string            `json:"error"`
+	Next     time.Time         `json:"next"` // Time of the next attempt
+	At       time.Time         `json:"at"`   // Time of the last attempt
+}
+
+// New creates a delivery due now, the body is signed
+// with the secret of the webhook if it has one.
+//
+// Parameters:
+// - method: The request method, defaults to POST.
+// - url: The endpoint of the webhook.
+// - headers: The request headers.
+// - body: The request body.
+// - secret: The signing secret, empty for unsigned deliveries.
+//
+// Returns:
+// - The Delivery.
+func New(method string, url string, headers map[string]string, body []byte, secret string) *Delivery {
+	if method == "" {
+		method = http.MethodPost
+	}
+	id := make([]byte, 16)
+	rand.Read(id)
+	delivery := &Delivery{
+		ID:      hex.EncodeToString(id),
+		URL:     url,
+		Method:  method,
+		Headers: make(map[string]string),
+		Body:    body,
+		Status:  PENDING,
+		Next:    time.Now(),
+	}
+	for key, value := range headers {
+		delivery.Headers[key] = value
+	}
+	delivery.Headers[DELIVERY] = delivery.ID
+	if secret != "" {
+		delivery.Headers[SIGNATURE] = Sign(secret, body)
+	}
+	return delivery
+}
+
+// Sign returns the signature header value of a body
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff returns the delay after a number of failed attempts
+func Backoff(attempts int) time.Duration {
+	delay := BACKOFF
+	for i := 1; i < attempts && delay < MAX_BACKOFF; i++ {
+		delay *= 2
+	}
+	return min(delay, MAX_BACKOFF)
+}
+
+// Queue stores deliveries in a storage until their endpoint accepts
+// them, deliveries survive restarts and are retried with backoff
+type Queue struct {
+	storage   fs.Storage
+	client    *http.Client
+	report    func(*Delivery) // Called after each attempt
+	running   sync.Mutex      // One run at a time
+	reporting sync.Mutex      // One report at a time
+	lock      sync.Mutex      // Guards stop, done and busy
+	busy      map[string]bool // Endpoint hosts being attempted
+	attempts  sync.WaitGroup  // Endpoint hosts being attempted
+	wake      chan struct{}
+	stop      chan struct{}
+	done      chan struct{} // Closed once the background run returns
+}
+
+// NewQueue creates a Queue of the deliveries in the QUEUE_DIR of a storage.
+//
+// Parameters:
+// - storage: The storage of the deliveries, usually the data mount.
+// - report: Called with the delivery after each attempt, may be nil.
+//
+// Returns:
+// - The Queue.
+func NewQueue(storage fs.Storage, report func(*Delivery)) *Queue {
+	return &Queue{
+		storage: storage,
+		client:  &http.Client{Timeout: TIMEOUT},
+		report:  report,
+		busy:    make(map[string]bool),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Enqueue stores a delivery and wakes the queue up
+func (q *Queue) Enqueue(delivery *Delivery) error {
+	if err := q.save(delivery); err != nil {
+		return err
+	}
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Start runs the queue in the background until Stop
+func (q *Queue) Start() {
+	q.lock.Lock()
+	if q.stop != nil {
+		q.lock.Unlock()
+		return
+	}
+	stop, done := make(chan struct{}), make(chan struct{})
+	q.stop, q.done = stop, done
+	q.lock.Unlock()
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(INTERVAL)
+		defer ticker.Stop()
+		for {
+			if err := q.Run(time.Now()); err != nil {
+				log.Error(err)
+			}
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			case <-q.wake:
+			}
+		}
+	}()
+}
+
+// Stop stops the background run of the queue and waits for
+// the deliveries being attempted, the storage of the queue is
+// then free to be closed
+func (q *Queue) Stop() {
+	q.lock.Lock()
+	if q.stop == nil {
+		q.lock.Unlock()
+		return
+	}
+	done := q.done
+	close(q.stop)
+	q.stop, q.done = nil, nil
+	q.lock.Unlock()
+	<-done
+	q.Wait()
+}
+
+// Wait waits for the deliveries being attempted
+func (q *Queue) Wait() {
+	q.attempts.Wait()
+}
+
+// Pending returns the stored deliveries oldest first
+func (q *Queue) Pending() ([]*Delivery, error) {
+	entries, err := q.storage.List(QUEUE_DIR)
+	if err != nil {
+		if errors.Is(err, iofs.ErrNotExist) {
+			return make([]*Delivery, 0), nil
+		}
+		return nil, err
+	}
+	deliveries := make([]*Delivery, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsFile() {
+			continue
+		}
+		content, err := q.storage.Read(path.Join(QUEUE_DIR, entry.Filename()))
+		if err != nil {
+			return nil, err
+		}
+		delivery := &Delivery{}
+		if err := json.Unmarshal(content, delivery); err != nil {
+			log.Errorf("webhook %s: %v", entry.Filename(), err)
+			continue
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		return deliveries[i].Next.Before(deliveries[j].Next)
+	})
+	return deliveries, nil
+}
+
+// Run starts attempting the deliveries due at a time without waiting
+// for them. Endpoints are attempted concurrently so a hanging one only
+// holds up its own deliveries, endpoints still being attempted from a
+// previous run and endpoints past WORKERS wait for a later run.
+//
+// Parameters:
+// - now: The time deliveries are due at.
+//
+// Returns:
+// - An error if the deliveries cannot be read.
+func (q *Queue) Run(now time.Time) error {
+	q.running.Lock()
+	defer q.running.Unlock()
+	deliveries, err := q.Pending()
+	if err != nil {
+		return err
+	}
+
+	hosts := make([]string, 0)
+	due := make(map[string][]*Delivery)
+	for _, delivery := range deliveries {
+		if delivery.Next.After(now) {
+			continue
+		}
+		host := delivery.URL
+		if parsed, err := url.Parse(delivery.URL); err == nil {
+			host = parsed.Host
+		}
+		if _, ok := due[host]; !ok {
+			hosts = append(hosts, host)
+		}
+		due[host] = append(due[host], delivery)
+	}
+
+	for _, host := range hosts {
+		q.lock.Lock()
+		if q.busy[host] || len(q.busy) >= WORKERS {
+			q.lock.Unlock()
+			continue
+		}
+		q.busy[host] = true
+		q.attempts.Add(1)
+		q.lock.Unlock()
+
+		go func(host string, deliveries []*Delivery) {
+			defer q.attempts.Done()
+			defer func() {
+				q.lock.Lock()
+				delete(q.busy, host)
+				q.lock.Unlock()
+			}()
+			for _, delivery := range deliveries {
+				q.attempt(delivery, now)
+				if err := q.finish(delivery); err != nil {
+					log.Errorf("webhook %s: %v", delivery.ID, err)
+				}
+			}
+		}(host, due[host])
+	}
+	return nil
+}
+
+// finish stores an attempted delivery, or removes it once
+// delivered or failed, and reports it
+func (q *Queue) finish(delivery *Delivery) error {
+	var err error
+	if delivery.Status == PENDING {
+		err = q.save(delivery)
+	} else {
+		err = q.storage.Remove(q.file(delivery))
+	}
+	if err != nil {
+		return err
+	}
+	if q.report != nil {
+		q.reporting.Lock()
+		defer q.reporting.Unlock()
+		q.report(delivery)
+	}
+	return nil
+}
+
+// attempt sends a delivery and updates its status
+func (q *Queue) attempt(delivery *Delivery, now time.Time) {
+	delivery.Attempts++
+	delivery.At = now
+	delivery.Code = 0
+	delivery.Error = ""
+
+	err := q.send(delivery)
+	switch {
+	case err == nil:
+		delivery.Status = DELIVERED
+	case delivery.Attempts >= ATTEMPTS:
+		delivery.Status = FAILED
+		delivery.Error = err.Error()
+	default:
+		delivery.Error = err.Error()
+		delivery.Next = now.Add(Backoff(delivery.Attempts))
+	}
+}
+
+func (q *Queue) send(delivery *Delivery) error {
+	request, err := http.NewRequest(delivery.Method, delivery.URL, bytes.NewReader(delivery.Body))
+	if err != nil {
+		return err
+	}
+	for key, value := range delivery.Headers {
+		request.Header.Set(key, value)
+	}
+	response, err := q.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 1<<20))
+	delivery.Code = response.StatusCode
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("%s %s: %s", delivery.Method, delivery.URL, response.Status)
+	}
+	return nil
+}
+
+func (q *Queue) save(delivery *Delivery) error {
+	content, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+	return q.storage.Dump(q.file(delivery), content)
+}
+
+// file returns the path of a delivery, listings of a storage
+// only have files of its extension
+func (q *Queue) file(delivery *Delivery) string {
+	return path.Join(QUEUE_DIR, delivery.ID+"."+q.storage.Ext())
+}
+
+// Payload is the default body of a delivery, the submission as json
+//
+// Parameters:
+// - form: The name of the form.
+// - doc: The name of the submission.
+// - data: The frontmatter of the submission.
+// - body: The body of the submission.
+// - at: The time of the submission.
+//
+// Returns:
+// - The json body.
+// - An error if a value cannot be encoded.
+func Payload(form string, doc string, data map[string]interface{}, body string, at time.Time) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"form":      form,
+		"doc":       doc,
+		"data":      jsonable(data),
+		"body":      body,
+		"timestamp": at.Unix(),
+	})
+}
+
+// jsonable converts the yaml maps of a value to maps json can encode
+func jsonable(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			converted[fmt.Sprint(key)] = jsonable(item)
+		}
+		return converted
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			converted[key] = jsonable(item)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(value))
+		for i, item := range value {
+			converted[i] = jsonable(item)
+		}
+		return converted
+	default:
+		return value
+	}
+}
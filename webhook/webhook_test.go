@@ -0,0 +1,166 @@
+package webhook
+
+import (
+	"io"
+	"julien/fs"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, Backoff(1))
+	assert.Equal(t, time.Minute, Backoff(2))
+	assert.Equal(t, 4*time.Minute, Backoff(4))
+	assert.Equal(t, MAX_BACKOFF, Backoff(20))
+}
+
+func TestQueue(t *testing.T) {
+	var calls atomic.Int32
+	var signature, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, _ := io.ReadAll(r.Body)
+		body, signature = string(content), r.Header.Get(SIGNATURE)
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	storage := fs.NewMemory("index", "md")
+	reports := make([]Delivery, 0)
+	queue := NewQueue(storage, func(delivery *Delivery) {
+		reports = append(reports, *delivery)
+	})
+	assert.NoError(t, queue.Run(time.Now()))
+	queue.Wait()
+
+	delivery := New("", server.URL, map[string]string{"Content-Type": "application/json"}, []byte(`{"name":"bob"}`), "secret")
+	delivery.Form, delivery.Doc, delivery.Hook = "contact", "bob", "crm"
+	assert.NoError(t, queue.Enqueue(delivery))
+	now := time.Now()
+
+	// Failed attempts wait for their backoff
+	assert.NoError(t, queue.Run(now))
+	queue.Wait()
+	assert.Len(t, reports, 1)
+	assert.Equal(t, PENDING, reports[0].Status)
+	assert.Equal(t, http.StatusBadGateway, reports[0].Code)
+	assert.NoError(t, queue.Run(now.Add(10*time.Second)))
+	queue.Wait()
+	assert.Equal(t, int32(1), calls.Load())
+
+	// Deliveries survive a restart
+	queue = NewQueue(storage, func(delivery *Delivery) {
+		reports = append(reports, *delivery)
+	})
+	pending, err := queue.Pending()
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.NoError(t, queue.Run(now.Add(BACKOFF)))
+	queue.Wait()
+	assert.Len(t, reports, 2)
+	assert.Equal(t, DELIVERED, reports[1].Status)
+	assert.Equal(t, 2, reports[1].Attempts)
+	assert.Equal(t, `{"name":"bob"}`, body)
+	assert.Equal(t, Sign("secret", []byte(body)), signature)
+	pending, _ = queue.Pending()
+	assert.Empty(t, pending)
+}
+
+func TestQueueFailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	var last Delivery
+	queue := NewQueue(fs.NewMemory("index", "md"), func(delivery *Delivery) {
+		last = *delivery
+	})
+	assert.NoError(t, queue.Enqueue(New("PUT", server.URL, nil, nil, "")))
+	now := time.Now()
+	for i := 0; i < ATTEMPTS; i++ {
+		now = now.Add(MAX_BACKOFF)
+		assert.NoError(t, queue.Run(now))
+		queue.Wait()
+	}
+	assert.Equal(t, FAILED, last.Status)
+	assert.Equal(t, ATTEMPTS, last.Attempts)
+	assert.Contains(t, last.Error, "500")
+	pending, _ := queue.Pending()
+	assert.Empty(t, pending)
+}
+
+func TestQueueHanging(t *testing.T) {
+	release := make(chan struct{})
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer hanging.Close()
+	unblock := sync.OnceFunc(func() { close(release) })
+	defer unblock()
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer fast.Close()
+
+	reports := make(chan Delivery, 4)
+	queue := NewQueue(fs.NewMemory("index", "md"), func(delivery *Delivery) {
+		reports <- *delivery
+	})
+	slow := New("", hanging.URL, nil, nil, "")
+	assert.NoError(t, queue.Enqueue(slow))
+	assert.NoError(t, queue.Enqueue(New("", fast.URL, nil, nil, "")))
+	now := time.Now()
+
+	// The fast endpoint is delivered while the other one hangs
+	assert.NoError(t, queue.Run(now))
+	select {
+	case report := <-reports:
+		assert.Equal(t, fast.URL, report.URL)
+		assert.Equal(t, DELIVERED, report.Status)
+	case <-time.After(TIMEOUT / 2):
+		t.Fatal("hanging endpoint held up the queue")
+	}
+
+	// Later runs deliver to other endpoints without
+	// attempting the hanging one again
+	later := New("", fast.URL, nil, nil, "")
+	assert.NoError(t, queue.Enqueue(later))
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(TIMEOUT / 2)
+	for delivered := false; !delivered; {
+		assert.NoError(t, queue.Run(time.Now()))
+		select {
+		case report := <-reports:
+			assert.Equal(t, later.ID, report.ID)
+			assert.Equal(t, DELIVERED, report.Status)
+			delivered = true
+		case <-ticker.C:
+		case <-timeout:
+			t.Fatal("hanging endpoint held up the next runs")
+		}
+	}
+
+	unblock()
+	queue.Wait()
+	report := <-reports
+	assert.Equal(t, slow.ID, report.ID)
+	assert.Equal(t, 1, report.Attempts)
+	assert.Empty(t, reports)
+}
+
+func TestPayload(t *testing.T) {
+	data := map[string]interface{}{
+		"name": "bob",
+		"cv":   []interface{}{map[interface{}]interface{}{"name": "cv.pdf", "size": 12}},
+	}
+	payload, err := Payload("contact", "bob", data, "Hello", time.Unix(1714557600, 0))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"form":"contact","doc":"bob","body":"Hello","timestamp":1714557600,"data":{"name":"bob","cv":[{"name":"cv.pdf","size":12}]}}`, string(payload))
+}